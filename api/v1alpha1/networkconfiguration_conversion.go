@@ -0,0 +1,201 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/intel/network-operator/api/v1beta1"
+)
+
+var _ conversion.Convertible = &NetworkClusterPolicy{}
+
+// ConvertTo converts the policy to the v1beta1 hub version. The log level
+// moves under gaudiScaleOut as it is the level of the discovery agent, and
+// the node agent pod template, rollout and readiness taint join it there.
+// The node specific errors are rebuilt from the error nodes.
+func (src *NetworkClusterPolicy) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*v1beta1.NetworkClusterPolicy)
+	if !ok {
+		return fmt.Errorf("unsupported conversion target %T", dstRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	spec := src.Spec.DeepCopy()
+
+	dst.Spec = v1beta1.NetworkClusterPolicySpec{
+		ConfigurationType: spec.ConfigurationType,
+		NodeSelector:      spec.NodeSelector,
+		NodeLabelSelector: spec.NodeLabelSelector,
+		GaudiScaleOut: v1beta1.GaudiScaleOutSpec{
+			DisableNetworkManager: spec.GaudiScaleOut.DisableNetworkManager,
+			Layer:                 spec.GaudiScaleOut.Layer,
+			Image:                 spec.GaudiScaleOut.Image,
+			PullPolicy:            spec.GaudiScaleOut.PullPolicy,
+			ImagePullSecrets:      spec.GaudiScaleOut.ImagePullSecrets,
+			MTU:                   spec.GaudiScaleOut.MTU,
+			ApplyPolicy:           spec.GaudiScaleOut.ApplyPolicy,
+			MinReadyPorts:         spec.GaudiScaleOut.MinReadyPorts,
+			Verification:          (*v1beta1.VerificationSpec)(spec.GaudiScaleOut.Verification),
+			Persistence:           spec.GaudiScaleOut.Persistence,
+			NodeFeatureRule:       (*v1beta1.NodeFeatureRuleSpec)(spec.GaudiScaleOut.NodeFeatureRule),
+			LogLevel:              spec.LogLevel,
+			PodTemplate:           (*v1beta1.PodTemplateOverrides)(spec.PodTemplate),
+			ReadinessTaint:        (*v1beta1.ReadinessTaintSpec)(spec.ReadinessTaint),
+		},
+	}
+
+	if rollout := spec.Rollout; rollout != nil {
+		dst.Spec.GaudiScaleOut.Rollout = &v1beta1.RolloutSpec{
+			MaxUnavailable: rollout.MaxUnavailable,
+			Paused:         rollout.Paused,
+			Canary:         (*v1beta1.CanarySpec)(rollout.Canary),
+		}
+	}
+
+	dst.Status = v1beta1.NetworkClusterPolicyStatus{
+		Targets:    src.Status.Targets,
+		ReadyNodes: src.Status.ReadyNodes,
+		State:      src.Status.State,
+	}
+
+	nodes := src.Status.ErrorNodes
+	if len(nodes) != len(src.Status.Errors) {
+		nodes = nil
+	}
+
+	for i, msg := range src.Status.Errors {
+		policyErr := v1beta1.PolicyError{Message: msg}
+
+		if nodes != nil && nodes[i] != "" {
+			policyErr.Node = nodes[i]
+
+			// undo the node prefix of ErrorMessages
+			if rest, ok := strings.CutPrefix(msg, nodes[i]+": "); ok && !strings.Contains(rest, nodes[i]) {
+				policyErr.Message = rest
+			}
+		}
+
+		dst.Status.Errors = append(dst.Status.Errors, policyErr)
+	}
+
+	if rollout := src.Status.Rollout; rollout != nil {
+		dst.Status.Rollout = &v1beta1.RolloutStatus{
+			Revision:         rollout.Revision,
+			Phase:            rollout.Phase,
+			CanaryNodes:      rollout.CanaryNodes,
+			HaltedGeneration: rollout.HaltedGeneration,
+		}
+
+		for _, revision := range rollout.Revisions {
+			dst.Status.Rollout.Revisions = append(dst.Status.Rollout.Revisions, v1beta1.RevisionStatus(revision))
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts the policy from the v1beta1 hub version. The errors
+// are flattened and their nodes kept in the error nodes for the way back.
+func (dst *NetworkClusterPolicy) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*v1beta1.NetworkClusterPolicy)
+	if !ok {
+		return fmt.Errorf("unsupported conversion source %T", srcRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	spec := src.Spec.DeepCopy()
+
+	dst.Spec = NetworkClusterPolicySpec{
+		ConfigurationType: spec.ConfigurationType,
+		NodeSelector:      spec.NodeSelector,
+		NodeLabelSelector: spec.NodeLabelSelector,
+		GaudiScaleOut: GaudiScaleOutSpec{
+			DisableNetworkManager: spec.GaudiScaleOut.DisableNetworkManager,
+			Layer:                 spec.GaudiScaleOut.Layer,
+			Image:                 spec.GaudiScaleOut.Image,
+			PullPolicy:            spec.GaudiScaleOut.PullPolicy,
+			ImagePullSecrets:      spec.GaudiScaleOut.ImagePullSecrets,
+			MTU:                   spec.GaudiScaleOut.MTU,
+			ApplyPolicy:           spec.GaudiScaleOut.ApplyPolicy,
+			MinReadyPorts:         spec.GaudiScaleOut.MinReadyPorts,
+			Verification:          (*VerificationSpec)(spec.GaudiScaleOut.Verification),
+			Persistence:           spec.GaudiScaleOut.Persistence,
+			NodeFeatureRule:       (*NodeFeatureRuleSpec)(spec.GaudiScaleOut.NodeFeatureRule),
+		},
+		LogLevel:       spec.GaudiScaleOut.LogLevel,
+		PodTemplate:    (*PodTemplateOverrides)(spec.GaudiScaleOut.PodTemplate),
+		ReadinessTaint: (*ReadinessTaintSpec)(spec.GaudiScaleOut.ReadinessTaint),
+	}
+
+	if rollout := spec.GaudiScaleOut.Rollout; rollout != nil {
+		dst.Spec.Rollout = &RolloutSpec{
+			MaxUnavailable: rollout.MaxUnavailable,
+			Paused:         rollout.Paused,
+			Canary:         (*CanarySpec)(rollout.Canary),
+		}
+	}
+
+	dst.Status = NetworkClusterPolicyStatus{
+		Targets:    src.Status.Targets,
+		ReadyNodes: src.Status.ReadyNodes,
+		State:      src.Status.State,
+		Errors:     ErrorMessages(src.Status.Errors),
+	}
+
+	if slices.ContainsFunc(src.Status.Errors, func(policyErr v1beta1.PolicyError) bool { return policyErr.Node != "" }) {
+		for _, policyErr := range src.Status.Errors {
+			dst.Status.ErrorNodes = append(dst.Status.ErrorNodes, policyErr.Node)
+		}
+	}
+
+	if rollout := src.Status.Rollout; rollout != nil {
+		dst.Status.Rollout = &RolloutStatus{
+			Revision:         rollout.Revision,
+			Phase:            rollout.Phase,
+			CanaryNodes:      rollout.CanaryNodes,
+			HaltedGeneration: rollout.HaltedGeneration,
+		}
+
+		for _, revision := range rollout.Revisions {
+			dst.Status.Rollout.Revisions = append(dst.Status.Rollout.Revisions, RevisionStatus(revision))
+		}
+	}
+
+	return nil
+}
+
+// ErrorMessages flattens the v1beta1 errors to the v1alpha1 error list. Node
+// specific errors get the node name as a prefix unless they mention it.
+func ErrorMessages(errors []v1beta1.PolicyError) []string {
+	messages := make([]string, 0, len(errors))
+
+	for _, policyErr := range errors {
+		msg := policyErr.Message
+		if policyErr.Node != "" && !strings.Contains(msg, policyErr.Node) {
+			msg = fmt.Sprintf("%s: %s", policyErr.Node, msg)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/intel/network-operator/api/v1beta1"
+)
+
+var _ = Describe("NetworkClusterPolicy conversion", func() {
+	minReady := intstr.FromString("50%")
+	maxUnavailable := intstr.FromInt32(2)
+
+	policy := NetworkClusterPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: NetworkClusterPolicySpec{
+			ConfigurationType: gaudiScaleOut,
+			NodeSelector:      map[string]string{"foo": "bar"},
+			GaudiScaleOut: GaudiScaleOutSpec{
+				Layer:            "L3",
+				Image:            "intel/intel-network-linkdiscovery:latest",
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-secret"}},
+				MTU:              8000,
+				MinReadyPorts:    &minReady,
+				Verification:     &VerificationSpec{Attempts: 2, Targets: []string{"10.210.0.1"}},
+				Persistence:      "systemd-networkd",
+				NodeFeatureRule:  &NodeFeatureRuleSpec{DeviceIDs: []string{"1070"}},
+			},
+			LogLevel: 3,
+			PodTemplate: &PodTemplateOverrides{
+				PriorityClassName: "system-node-critical",
+			},
+			Rollout: &RolloutSpec{
+				MaxUnavailable: &maxUnavailable,
+				Canary:         &CanarySpec{Percentage: 10},
+			},
+			ReadinessTaint: &ReadinessTaintSpec{Key: "example.com/not-ready"},
+		},
+		Status: NetworkClusterPolicyStatus{
+			Targets:    2,
+			ReadyNodes: 1,
+			State:      "Degraded",
+			Errors:     []string{"node node-1 is also targeted by other"},
+			Rollout: &RolloutStatus{
+				Revision:         2,
+				Phase:            "Halted",
+				CanaryNodes:      1,
+				HaltedGeneration: 3,
+				Revisions:        []RevisionStatus{{Revision: 2, Nodes: 1, ReadyNodes: 1}, {Revision: 1, Nodes: 1, ReadyNodes: 1}},
+			},
+		},
+	}
+
+	It("Should move the node agent settings under gaudiScaleOut in v1beta1", func() {
+		hub := &v1beta1.NetworkClusterPolicy{}
+
+		Expect(policy.DeepCopy().ConvertTo(hub)).To(Succeed())
+		Expect(hub.Name).To(Equal("test"))
+		Expect(hub.Spec.GaudiScaleOut.LogLevel).To(Equal(3))
+		Expect(hub.Spec.GaudiScaleOut.Verification.Targets).To(Equal([]string{"10.210.0.1"}))
+		Expect(hub.Spec.GaudiScaleOut.PodTemplate.PriorityClassName).To(Equal("system-node-critical"))
+		Expect(hub.Spec.GaudiScaleOut.Rollout.Canary.Percentage).To(BeEquivalentTo(10))
+		Expect(hub.Spec.GaudiScaleOut.ReadinessTaint.Key).To(Equal("example.com/not-ready"))
+		Expect(hub.Status.Errors).To(Equal([]v1beta1.PolicyError{{Message: "node node-1 is also targeted by other"}}))
+	})
+
+	It("Should convert to v1beta1 and back without losing anything", func() {
+		hub := &v1beta1.NetworkClusterPolicy{}
+		Expect(policy.DeepCopy().ConvertTo(hub)).To(Succeed())
+
+		converted := &NetworkClusterPolicy{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(*converted).To(Equal(policy))
+	})
+
+	It("Should prefix node errors with the node name in v1alpha1", func() {
+		hub := &v1beta1.NetworkClusterPolicy{
+			Status: v1beta1.NetworkClusterPolicyStatus{
+				Errors: []v1beta1.PolicyError{
+					{Node: "node-1", Message: "cleanup failed"},
+					{Message: "invalid configuration"},
+				},
+			},
+		}
+
+		converted := &NetworkClusterPolicy{}
+		Expect(converted.ConvertFrom(hub)).To(Succeed())
+		Expect(converted.Status.Errors).To(Equal([]string{"node-1: cleanup failed", "invalid configuration"}))
+	})
+
+	It("Should keep the nodes of the errors through a round trip from v1beta1", func() {
+		hub := &v1beta1.NetworkClusterPolicy{}
+		Expect(policy.DeepCopy().ConvertTo(hub)).To(Succeed())
+
+		hub.Status.Errors = []v1beta1.PolicyError{
+			{Node: "node-1", Message: "cleanup failed"},
+			{Node: "node-2", Message: "node node-2 is also targeted by other"},
+			{Message: "invalid configuration"},
+		}
+
+		spoke := &NetworkClusterPolicy{}
+		Expect(spoke.ConvertFrom(hub.DeepCopy())).To(Succeed())
+		Expect(spoke.Status.Errors).To(Equal([]string{"node-1: cleanup failed", "node node-2 is also targeted by other", "invalid configuration"}))
+		Expect(spoke.Status.ErrorNodes).To(Equal([]string{"node-1", "node-2", ""}))
+		Expect(spoke.Annotations).To(BeEmpty())
+
+		converted := &v1beta1.NetworkClusterPolicy{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(*converted).To(Equal(*hub))
+	})
+
+	It("Should ignore error nodes not matching the errors", func() {
+		spoke := policy.DeepCopy()
+		spoke.Status.Errors = []string{"node-1: cleanup failed", "invalid configuration"}
+		spoke.Status.ErrorNodes = []string{"node-1"}
+
+		converted := &v1beta1.NetworkClusterPolicy{}
+		Expect(spoke.ConvertTo(converted)).To(Succeed())
+		Expect(converted.Status.Errors).To(Equal([]v1beta1.PolicyError{
+			{Message: "node-1: cleanup failed"},
+			{Message: "invalid configuration"},
+		}))
+	})
+})
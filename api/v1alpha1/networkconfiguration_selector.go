@@ -0,0 +1,86 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// TargetNodeSelector returns the selector of the nodes targeted by the policy,
+// combining the node selector and the node label selector.
+func (s *NetworkClusterPolicySpec) TargetNodeSelector() (labels.Selector, error) {
+	selector := labels.SelectorFromSet(s.NodeSelector)
+
+	if s.NodeLabelSelector != nil {
+		labelSelector, err := metav1.LabelSelectorAsSelector(s.NodeLabelSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		reqs, _ := labelSelector.Requirements()
+		selector = selector.Add(reqs...)
+	}
+
+	return selector, nil
+}
+
+// OverlappingNodes returns the given nodes targeted both by the policy and by
+// other policies of the same configuration type, with the names of the other
+// policies. Policies being deleted are ignored.
+func OverlappingNodes(policy *NetworkClusterPolicy, others []NetworkClusterPolicy, nodes []corev1.Node) (map[string][]string, error) {
+	selector, err := policy.Spec.TargetNodeSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []corev1.Node{}
+	for _, node := range nodes {
+		if selector.Matches(labels.Set(node.Labels)) {
+			targets = append(targets, node)
+		}
+	}
+
+	overlapping := map[string][]string{}
+
+	for i := range others {
+		other := &others[i]
+
+		if other.Name == policy.Name || !other.DeletionTimestamp.IsZero() ||
+			other.Spec.ConfigurationType != policy.Spec.ConfigurationType {
+			continue
+		}
+
+		otherSelector, err := other.Spec.TargetNodeSelector()
+		if err != nil {
+			continue
+		}
+
+		for _, node := range targets {
+			if otherSelector.Matches(labels.Set(node.Labels)) {
+				overlapping[node.Name] = append(overlapping[node.Name], other.Name)
+			}
+		}
+	}
+
+	for _, names := range overlapping {
+		sort.Strings(names)
+	}
+
+	return overlapping, nil
+}
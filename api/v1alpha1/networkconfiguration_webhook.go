@@ -15,11 +15,26 @@
 package v1alpha1
 
 import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -32,26 +47,136 @@ const (
 	gaudiScaleOut = "gaudi-so"
 )
 
-type emptyNodeSelectorError struct{}
+// The validation errors name the invalid field in their message.
+
+type emptyNodeSelectorError struct {
+	err *field.Error
+}
 
 func (e emptyNodeSelectorError) Error() string {
-	return "empty node-selector"
+	return e.err.Error()
 }
 
-type invalidNodeSelector struct{}
+type invalidNodeSelector struct {
+	errs field.ErrorList
+}
 
 func (e invalidNodeSelector) Error() string {
-	return "invalid node selector"
+	return "invalid node selector: " + e.errs.ToAggregate().Error()
+}
+
+type invalidImageError struct {
+	err *field.Error
+}
+
+func (e invalidImageError) Error() string {
+	return e.err.Error()
+}
+
+type invalidMTUError struct {
+	err *field.Error
+}
+
+func (e invalidMTUError) Error() string {
+	return e.err.Error()
+}
+
+type invalidLayerSettingsError struct {
+	err *field.Error
+}
+
+func (e invalidLayerSettingsError) Error() string {
+	return e.err.Error()
+}
+
+type invalidMinReadyPortsError struct {
+	err *field.Error
+}
+
+func (e invalidMinReadyPortsError) Error() string {
+	return e.err.Error()
+}
+
+type invalidVerificationError struct {
+	err *field.Error
+}
+
+func (e invalidVerificationError) Error() string {
+	return e.err.Error()
+}
+
+type invalidPersistenceError struct {
+	err *field.Error
 }
 
-type unknownConfigurationError struct{}
+func (e invalidPersistenceError) Error() string {
+	return e.err.Error()
+}
+
+type invalidPodTemplateError struct {
+	err *field.Error
+}
+
+func (e invalidPodTemplateError) Error() string {
+	return e.err.Error()
+}
+
+type invalidRolloutError struct {
+	err *field.Error
+}
+
+func (e invalidRolloutError) Error() string {
+	return e.err.Error()
+}
+
+type invalidNodeFeatureRuleError struct {
+	err *field.Error
+}
+
+func (e invalidNodeFeatureRuleError) Error() string {
+	return e.err.Error()
+}
+
+type invalidReadinessTaintError struct {
+	err *field.Error
+}
+
+func (e invalidReadinessTaintError) Error() string {
+	return e.err.Error()
+}
+
+type immutableFieldError struct {
+	err *field.Error
+}
+
+func (e immutableFieldError) Error() string {
+	return e.err.Error()
+}
+
+type overlappingPolicyError struct {
+	nodes map[string][]string
+}
+
+func (e overlappingPolicyError) Error() string {
+	return "nodes already targeted by other policies: " + describeOverlap(e.nodes)
+}
+
+type unknownConfigurationError struct {
+	err *field.Error
+}
 
 func (e unknownConfigurationError) Error() string {
-	return "unknown error"
+	return e.err.Error()
 }
 
+// webhookClient reads the nodes and the other policies for the overlap check.
+// The check is skipped without it.
+var webhookClient client.Reader
+
 // SetupWebhookWithManager will setup the manager to manage the webhooks
 func (r *NetworkClusterPolicy) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookClient = mgr.GetClient()
+
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -80,46 +205,207 @@ func (r *NetworkClusterPolicy) Default() {
 
 var _ webhook.Validator = &NetworkClusterPolicy{}
 
-var labelHostRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_\.]*)?[A-Za-z0-9]$`)
-var labelPathRegex = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-\._\/]*)?[A-Za-z0-9]$`)
-var labelValueRegex = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+// imageRegex is the image reference format of the container runtimes:
+// [registry[:port]/]path[:tag][@digest]
+var imageRegex = regexp.MustCompile(`^((?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])` +
+	`(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	`(?::[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?` +
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9A-Fa-f]{32,})?$`)
+
+const (
+	minMTU = 1500
+	maxMTU = 9000
+)
+
+var deviceIDRegex = regexp.MustCompile(`^[0-9a-f]{4}$`)
 
 func validateGaudiSoSpec(s GaudiScaleOutSpec) error {
-	return nil
-}
+	path := field.NewPath("spec", "gaudiScaleOut")
 
-func validateNodeSelector(nodeSelector map[string]string) error {
-	if len(nodeSelector) == 0 {
-		return emptyNodeSelectorError{}
+	if s.Image != "" && (len(s.Image) > 255 || !imageRegex.MatchString(s.Image)) {
+		return invalidImageError{field.Invalid(path.Child("image"), s.Image, "not a valid image reference")}
 	}
 
-	for k, v := range nodeSelector {
-		if len(k) > 253 || len(v) > 63 {
-			return invalidNodeSelector{}
+	// both layers configure the MTU on the interfaces with the same limits
+	if s.MTU != 0 && (s.MTU < minMTU || s.MTU > maxMTU) {
+		return invalidMTUError{field.Invalid(path.Child("mtu"), s.MTU,
+			fmt.Sprintf("must be between %d and %d", minMTU, maxMTU))}
+	}
+
+	// readiness and connectivity are only known for the L3 configuration
+	if s.Layer == "L2" {
+		if s.MinReadyPorts != nil {
+			return invalidLayerSettingsError{field.Forbidden(path.Child("minReadyPorts"), "only supported in L3 mode")}
 		}
 
-		if !labelValueRegex.MatchString(v) {
-			return invalidNodeSelector{}
+		if s.Verification != nil {
+			return invalidLayerSettingsError{field.Forbidden(path.Child("verification"), "only supported in L3 mode")}
 		}
+	}
+
+	if s.MinReadyPorts != nil {
+		minReady, err := intstr.GetScaledValueFromIntOrPercent(s.MinReadyPorts, 100, true)
+		if err != nil || minReady < 0 || (minReady > 100 && s.MinReadyPorts.Type == intstr.String) {
+			return invalidMinReadyPortsError{field.Invalid(path.Child("minReadyPorts"), s.MinReadyPorts.String(),
+				"must be a non-negative number or a percentage up to 100%")}
+		}
+	}
+
+	if v := s.Verification; v != nil {
+		if v.Timeout != nil && v.Timeout.Duration <= 0 {
+			return invalidVerificationError{field.Invalid(path.Child("verification", "timeout"), v.Timeout.Duration.String(),
+				"must be positive")}
+		}
+
+		for i, target := range v.Targets {
+			if ip := net.ParseIP(target); ip == nil || ip.To4() == nil {
+				return invalidVerificationError{field.Invalid(path.Child("verification", "targets").Index(i), target,
+					"must be an IPv4 address")}
+			}
+		}
+	}
+
+	// L2 interfaces have no addresses, only networkd keeps them up at boot
+	if s.Persistence != "" && s.Persistence != "none" && s.Persistence != "systemd-networkd" && s.Layer != "L3" {
+		return invalidPersistenceError{field.Forbidden(path.Child("persistence"),
+			fmt.Sprintf("%s is only supported in L3 mode", s.Persistence))}
+	}
+
+	// NetworkManager cannot own the interfaces it was told to leave alone
+	if s.Persistence == "NetworkManager" && s.DisableNetworkManager {
+		return invalidPersistenceError{field.Forbidden(path.Child("persistence"),
+			"NetworkManager cannot be used together with disableNetworkManager")}
+	}
 
-		parts := strings.SplitN(k, "/", 2)
-		if len(parts) == 1 && !labelHostRegex.MatchString(parts[0]) {
-			return invalidNodeSelector{}
-		} else if len(parts) == 2 {
-			if !labelHostRegex.MatchString(parts[0]) {
-				return invalidNodeSelector{}
+	if rule := s.NodeFeatureRule; rule != nil {
+		for i, id := range rule.DeviceIDs {
+			if !deviceIDRegex.MatchString(id) {
+				return invalidNodeFeatureRuleError{field.Invalid(path.Child("nodeFeatureRule", "deviceIDs").Index(i), id,
+					"must be four lowercase hexadecimal digits")}
 			}
-			if !labelPathRegex.MatchString(parts[1]) {
-				return invalidNodeSelector{}
+		}
+
+		for name, label := range map[string]string{"deviceLabel": rule.DeviceLabel, "readyLabel": rule.ReadyLabel} {
+			if msgs := validation.IsQualifiedName(label); label != "" && len(msgs) > 0 {
+				return invalidNodeFeatureRuleError{field.Invalid(path.Child("nodeFeatureRule", name), label,
+					strings.Join(msgs, "; "))}
 			}
 		}
+
+		if rule.DeviceLabel != "" && rule.DeviceLabel == rule.ReadyLabel {
+			return invalidNodeFeatureRuleError{field.Duplicate(path.Child("nodeFeatureRule", "readyLabel"), rule.ReadyLabel)}
+		}
+	}
+
+	return nil
+}
+
+// validateNodeSelector checks the node selection of the policy with the label
+// validation of the API server.
+func validateNodeSelector(nodeSelector map[string]string, labelSelector *metav1.LabelSelector) error {
+	if len(nodeSelector) == 0 &&
+		(labelSelector == nil || (len(labelSelector.MatchLabels) == 0 && len(labelSelector.MatchExpressions) == 0)) {
+		return emptyNodeSelectorError{field.Required(field.NewPath("spec", "nodeSelector"),
+			"nodeSelector or nodeLabelSelector is required")}
+	}
+
+	errs := metav1validation.ValidateLabels(nodeSelector, field.NewPath("spec", "nodeSelector"))
+	errs = append(errs, metav1validation.ValidateLabelSelector(labelSelector,
+		metav1validation.LabelSelectorValidationOptions{}, field.NewPath("spec", "nodeLabelSelector"))...)
+
+	if len(errs) > 0 {
+		return invalidNodeSelector{errs: errs}
+	}
+
+	return nil
+}
+
+// validatePodTemplate rejects overrides of the settings the operator relies on.
+func validatePodTemplate(t *PodTemplateOverrides) error {
+	if t == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "podTemplate")
+
+	if _, found := t.Labels["app"]; found {
+		return invalidPodTemplateError{field.Forbidden(path.Child("labels").Key("app"), "used by the operator")}
+	}
+
+	for i, env := range t.Env {
+		if env.Name == "NODE_NAME" {
+			return invalidPodTemplateError{field.Forbidden(path.Child("env").Index(i), "NODE_NAME is set by the operator")}
+		}
+	}
+
+	return nil
+}
+
+// validateRollout checks that the rollout always makes progress and that the
+// canary nodes are selected somehow.
+func validateRollout(r *RolloutSpec) error {
+	if r == nil {
+		return nil
+	}
+
+	path := field.NewPath("spec", "rollout")
+
+	if r.MaxUnavailable != nil {
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(r.MaxUnavailable, 100, true)
+		if err != nil || maxUnavailable < 1 || (maxUnavailable > 100 && r.MaxUnavailable.Type == intstr.String) {
+			return invalidRolloutError{field.Invalid(path.Child("maxUnavailable"), r.MaxUnavailable.String(),
+				"must be a positive number or a percentage between 1% and 100%")}
+		}
+	}
+
+	if c := r.Canary; c != nil {
+		if len(c.NodeSelector) == 0 && c.Percentage == 0 {
+			return invalidRolloutError{field.Required(path.Child("canary"), "nodeSelector or percentage is required")}
+		}
+
+		if errs := metav1validation.ValidateLabels(c.NodeSelector, path.Child("canary", "nodeSelector")); len(errs) > 0 {
+			return invalidRolloutError{errs[0]}
+		}
+
+		if c.Percentage < 0 || c.Percentage > 100 {
+			return invalidRolloutError{field.Invalid(path.Child("canary", "percentage"), c.Percentage,
+				"must be between 1 and 100")}
+		}
+	}
+
+	return nil
+}
+
+// validateReadinessTaint checks the taint key like the API server does for
+// the node taints.
+func validateReadinessTaint(t *ReadinessTaintSpec) error {
+	if t == nil || t.Key == "" {
+		return nil
+	}
+
+	if msgs := validation.IsQualifiedName(t.Key); len(msgs) > 0 {
+		return invalidReadinessTaintError{field.Invalid(field.NewPath("spec", "readinessTaint", "key"), t.Key,
+			strings.Join(msgs, "; "))}
 	}
 
 	return nil
 }
 
 func validateSpec(s NetworkClusterPolicySpec) (admission.Warnings, error) {
-	if err := validateNodeSelector(s.NodeSelector); err != nil {
+	if err := validateNodeSelector(s.NodeSelector, s.NodeLabelSelector); err != nil {
+		return nil, err
+	}
+
+	if err := validatePodTemplate(s.PodTemplate); err != nil {
+		return nil, err
+	}
+
+	if err := validateRollout(s.Rollout); err != nil {
+		return nil, err
+	}
+
+	if err := validateReadinessTaint(s.ReadinessTaint); err != nil {
 		return nil, err
 	}
 
@@ -127,22 +413,180 @@ func validateSpec(s NetworkClusterPolicySpec) (admission.Warnings, error) {
 	case gaudiScaleOut:
 		return nil, validateGaudiSoSpec(s.GaudiScaleOut)
 	default:
-		return nil, unknownConfigurationError{}
+		return nil, unknownConfigurationError{field.NotSupported(field.NewPath("spec", "configurationType"),
+			s.ConfigurationType, []string{gaudiScaleOut})}
+	}
+}
+
+// validateSpecUpdate rejects changes of immutable fields and warns about the
+// changes that reconfigure the network on all the targeted nodes.
+func validateSpecUpdate(s, old NetworkClusterPolicySpec) (admission.Warnings, error) {
+	if s.ConfigurationType != old.ConfigurationType {
+		return nil, immutableFieldError{field.Invalid(field.NewPath("spec", "configurationType"),
+			s.ConfigurationType, "field is immutable")}
+	}
+
+	warnings := admission.Warnings{}
+
+	if old.GaudiScaleOut.Layer == "L3" && s.GaudiScaleOut.Layer == "L2" {
+		warnings = append(warnings, "switching from L3 to L2 removes the addresses, routes and gaudinet configuration "+
+			"of the scale-out interfaces on all the targeted nodes")
+	}
+
+	if s.GaudiScaleOut.MTU != old.GaudiScaleOut.MTU {
+		warnings = append(warnings, fmt.Sprintf("changing the MTU from %s to %s applies to all the targeted nodes and their peers have to match",
+			mtuString(old.GaudiScaleOut.MTU), mtuString(s.GaudiScaleOut.MTU)))
+	}
+
+	if s.GaudiScaleOut.Persistence != old.GaudiScaleOut.Persistence {
+		warnings = append(warnings, "changing the persistence backend takes effect on the next boot of the targeted nodes")
+	}
+
+	if s.Rollout != nil && s.Rollout.Paused && !equalIgnoringRollout(s, old) {
+		warnings = append(warnings, "the rollout is paused, the changes reach the nodes once it is resumed")
+	}
+
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+
+	return warnings, nil
+}
+
+func equalIgnoringRollout(s, old NetworkClusterPolicySpec) bool {
+	s.Rollout, old.Rollout = nil, nil
+
+	return equality.Semantic.DeepEqual(s, old)
+}
+
+func mtuString(mtu int) string {
+	if mtu == 0 {
+		return "the default"
+	}
+
+	return strconv.Itoa(mtu)
+}
+
+func describeOverlap(nodes map[string][]string) string {
+	names := slices.Sorted(maps.Keys(nodes))
+
+	contested := make([]string, 0, len(names))
+	for _, name := range names {
+		contested = append(contested, fmt.Sprintf("%s (%s)", name, strings.Join(nodes[name], ", ")))
+	}
+
+	return strings.Join(contested, ", ")
+}
+
+// overlappingNodes returns the existing nodes the policy shares with other
+// policies.
+func overlappingNodes(reader client.Reader, r *NetworkClusterPolicy) (map[string][]string, error) {
+	ctx := context.TODO()
+
+	var policies NetworkClusterPolicyList
+	if err := reader.List(ctx, &policies); err != nil {
+		return nil, err
+	}
+
+	var nodes corev1.NodeList
+	if err := reader.List(ctx, &nodes); err != nil {
+		return nil, err
+	}
+
+	return OverlappingNodes(r, policies.Items, nodes.Items)
+}
+
+// validateOverlap rejects policies targeting nodes of other policies, as
+// their DaemonSets would fight over the same interfaces and files. On update
+// only newly contested nodes are rejected so that a conflict caused by node
+// label changes can be resolved one policy at a time.
+func validateOverlap(reader client.Reader, r *NetworkClusterPolicy, old *NetworkClusterPolicy) (admission.Warnings, error) {
+	if reader == nil {
+		return nil, nil
+	}
+
+	overlap, err := overlappingNodes(reader, r)
+	if err != nil {
+		netpolicylog.Error(err, "unable to check for overlapping policies", "name", r.Name)
+
+		return admission.Warnings{"could not check for overlapping policies"}, nil
+	}
+
+	if len(overlap) == 0 {
+		return nil, nil
 	}
+
+	if old != nil {
+		oldOverlap, err := overlappingNodes(reader, old)
+		if err == nil {
+			added := false
+			for node, names := range overlap {
+				for _, name := range names {
+					added = added || !slices.Contains(oldOverlap[node], name)
+				}
+			}
+
+			if !added {
+				return admission.Warnings{"nodes targeted by other policies: " + describeOverlap(overlap)}, nil
+			}
+		}
+	}
+
+	return nil, overlappingPolicyError{nodes: overlap}
 }
 
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *NetworkClusterPolicy) ValidateCreate() (admission.Warnings, error) {
 	netpolicylog.Info("validate create", "name", r.Name)
 
-	return validateSpec(r.Spec)
+	warnings, err := validateSpec(r.Spec)
+	if err != nil {
+		return warnings, err
+	}
+
+	return validateOverlap(webhookClient, r, nil)
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// Updates that leave the spec as it is, like the finalizer changes of the
+// controller, and updates of deleted policies are always accepted, so that a
+// policy made invalid by stricter rules or overlaps can still be deleted.
 func (r *NetworkClusterPolicy) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
 	netpolicylog.Info("validate update", "name", r.Name)
 
-	return validateSpec(r.Spec)
+	oldPolicy, ok := old.(*NetworkClusterPolicy)
+
+	if r.DeletionTimestamp != nil || (ok && equality.Semantic.DeepEqual(r.Spec, oldPolicy.Spec)) {
+		return nil, nil
+	}
+
+	warnings, err := validateSpec(r.Spec)
+	if err != nil {
+		return warnings, err
+	}
+
+	if !ok {
+		return validateOverlap(webhookClient, r, nil)
+	}
+
+	updateWarnings, err := validateSpecUpdate(r.Spec, oldPolicy.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	overlapWarnings, err := validateOverlap(webhookClient, r, oldPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings = append(warnings, updateWarnings...)
+	warnings = append(warnings, overlapWarnings...)
+
+	if len(warnings) == 0 {
+		return nil, nil
+	}
+
+	return warnings, nil
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -15,9 +15,16 @@
 package v1alpha1
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("NetworkClusterPolicy Webhook", func() {
@@ -52,7 +59,7 @@ var _ = Describe("NetworkClusterPolicy Webhook", func() {
 
 			nc.Spec.ConfigurationType = "foo bar"
 
-			Expect(nc.ValidateCreate()).Error().To(BeEquivalentTo(unknownConfigurationError{}))
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(unknownConfigurationError{}))
 		})
 
 		It("Should accept good nodeSelectors", func() {
@@ -109,6 +116,40 @@ var _ = Describe("NetworkClusterPolicy Webhook", func() {
 			}
 		})
 
+		It("Should validate node label selectors InputVal", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeLabelSelector: &v1.LabelSelector{
+						MatchLabels: map[string]string{"intel.feature.node.kubernetes.io/gaudi-ready": "true"},
+						MatchExpressions: []v1.LabelSelectorRequirement{{
+							Key:      "rack",
+							Operator: v1.LabelSelectorOpNotIn,
+							Values:   []string{"r17", "r18"},
+						}},
+					},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.NodeLabelSelector.MatchExpressions[0].Values = nil
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidNodeSelector{}))
+
+			nc.Spec.NodeLabelSelector.MatchExpressions[0].Operator = v1.LabelSelectorOpExists
+			nc.Spec.NodeLabelSelector.MatchLabels["foo.com"] = "_bar"
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidNodeSelector{}))
+
+			nc.Spec.NodeLabelSelector = &v1.LabelSelector{}
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(emptyNodeSelectorError{}))
+		})
+
 		It("Should accept update with good values and fail with bad ones InputVal", func() {
 			nc := NetworkClusterPolicy{
 				ObjectMeta: v1.ObjectMeta{
@@ -135,6 +176,478 @@ var _ = Describe("NetworkClusterPolicy Webhook", func() {
 			Expect(nc2.ValidateUpdate(&nc)).Error().NotTo(BeNil())
 		})
 
+		It("Should validate minReadyPorts InputVal", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			for _, v := range []intstr.IntOrString{intstr.FromInt32(20), intstr.FromString("90%")} {
+				nc.Spec.GaudiScaleOut.MinReadyPorts = &v
+
+				Expect(nc.ValidateCreate()).Error().To(BeNil(), "minReadyPorts: %s", v.String())
+			}
+
+			for _, v := range []intstr.IntOrString{intstr.FromInt32(-1), intstr.FromString("101%"), intstr.FromString("foo")} {
+				nc.Spec.GaudiScaleOut.MinReadyPorts = &v
+
+				Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidMinReadyPortsError{}), "minReadyPorts: %s", v.String())
+			}
+		})
+
+		It("Should validate verification settings InputVal", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+						Verification: &VerificationSpec{
+							Timeout: &v1.Duration{Duration: time.Second},
+							Targets: []string{"10.210.0.1"},
+						},
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.GaudiScaleOut.Verification.Targets = []string{"10.210.0.1", "fe80::1"}
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidVerificationError{}))
+
+			nc.Spec.GaudiScaleOut.Verification.Targets = nil
+			nc.Spec.GaudiScaleOut.Verification.Timeout.Duration = 0
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidVerificationError{}))
+		})
+
+		It("Should accept only networkd persistence in L2 mode", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer:       "L3",
+						Persistence: "systemd-networkd",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.GaudiScaleOut.Persistence = "NetworkManager"
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.GaudiScaleOut.DisableNetworkManager = true
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidPersistenceError{}))
+
+			nc.Spec.GaudiScaleOut.DisableNetworkManager = false
+			nc.Spec.GaudiScaleOut.Layer = "L2"
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidPersistenceError{}))
+
+			nc.Spec.GaudiScaleOut.Persistence = "systemd-networkd"
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.GaudiScaleOut.Persistence = "none"
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+		})
+
+		It("Should reject pod template overrides of operator settings", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+					PodTemplate: &PodTemplateOverrides{
+						Labels: map[string]string{"team": "ai"},
+						Env:    []corev1.EnvVar{{Name: "HTTP_PROXY", Value: "http://proxy:3128"}},
+					},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.PodTemplate.Labels["app"] = "other"
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidPodTemplateError{}))
+
+			delete(nc.Spec.PodTemplate.Labels, "app")
+			nc.Spec.PodTemplate.Env = append(nc.Spec.PodTemplate.Env, corev1.EnvVar{Name: "NODE_NAME", Value: "foo"})
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidPodTemplateError{}))
+		})
+
+		It("Should reject policies overlapping with existing ones", func() {
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			Expect(AddToScheme(scheme)).To(Succeed())
+
+			existing := &NetworkClusterPolicy{
+				ObjectMeta: v1.ObjectMeta{Name: "existing"},
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					NodeSelector:      map[string]string{"gaudi-ready": "true"},
+				},
+			}
+
+			nodes := []client.Object{
+				&corev1.Node{ObjectMeta: v1.ObjectMeta{Name: "node-1", Labels: map[string]string{"gaudi-ready": "true", "rack": "r1"}}},
+				&corev1.Node{ObjectMeta: v1.ObjectMeta{Name: "node-2", Labels: map[string]string{"gaudi-ready": "true", "rack": "r17"}}},
+			}
+
+			reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).WithObjects(nodes...).Build()
+
+			nc := &NetworkClusterPolicy{
+				ObjectMeta: v1.ObjectMeta{Name: "new"},
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					NodeSelector:      map[string]string{"rack": "r17"},
+				},
+			}
+
+			_, err := validateOverlap(reader, nc, nil)
+			Expect(err).To(BeAssignableToTypeOf(overlappingPolicyError{}))
+			Expect(err.Error()).To(ContainSubstring("node-2 (existing)"))
+
+			nc.Spec.NodeSelector["rack"] = "r18"
+
+			Expect(validateOverlap(reader, nc, nil)).Error().To(BeNil())
+
+			// an overlap that already existed is only warned about on update
+			old := nc.DeepCopy()
+			old.Spec.NodeSelector["rack"] = "r17"
+			nc.Spec.NodeSelector["rack"] = "r17"
+			nc.Spec.LogLevel = 2
+
+			warnings, err := validateOverlap(reader, nc, old)
+			Expect(err).To(BeNil())
+			Expect(warnings).To(HaveLen(1))
+
+			old.Spec.NodeSelector["rack"] = "r18"
+
+			Expect(validateOverlap(reader, nc, old)).Error().To(BeAssignableToTypeOf(overlappingPolicyError{}))
+		})
+
+		It("Should accept the finalizer removal of an invalid overlapping policy", func() {
+			scheme := runtime.NewScheme()
+			Expect(corev1.AddToScheme(scheme)).To(Succeed())
+			Expect(AddToScheme(scheme)).To(Succeed())
+
+			existing := &NetworkClusterPolicy{
+				ObjectMeta: v1.ObjectMeta{Name: "existing"},
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					NodeSelector:      map[string]string{"gaudi-ready": "true"},
+				},
+			}
+
+			node := &corev1.Node{ObjectMeta: v1.ObjectMeta{Name: "node-1", Labels: map[string]string{"gaudi-ready": "true"}}}
+
+			previous := webhookClient
+			webhookClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing, node).Build()
+			DeferCleanup(func() { webhookClient = previous })
+
+			// created before the stricter rules, and overlapping with the existing policy
+			old := &NetworkClusterPolicy{
+				ObjectMeta: v1.ObjectMeta{Name: "old", Finalizers: []string{"intel.com/network-cleanup"}},
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					NodeSelector:      map[string]string{"gaudi-ready": "true"},
+					GaudiScaleOut:     GaudiScaleOutSpec{Layer: "L3", MTU: 100},
+				},
+			}
+
+			Expect(old.ValidateCreate()).Error().NotTo(BeNil())
+
+			deleted := old.DeepCopy()
+			deleted.DeletionTimestamp = &v1.Time{}
+			deleted.Finalizers = nil
+
+			Expect(deleted.ValidateUpdate(old)).To(BeEmpty())
+
+			unfinalized := old.DeepCopy()
+			unfinalized.Finalizers = nil
+
+			Expect(unfinalized.ValidateUpdate(old)).To(BeEmpty())
+
+			// spec changes are still validated
+			unfinalized.Spec.LogLevel = 2
+
+			Expect(unfinalized.ValidateUpdate(old)).Error().NotTo(BeNil())
+		})
+
+		It("Should validate the image reference and MTU InputVal", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+						MTU:   8000,
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			goodImages := []string{
+				"intel/intel-network-linkdiscovery:latest",
+				"registry.example.com:5000/intel/linkdiscovery:0.1.0",
+				"linkdiscovery@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			}
+
+			for _, image := range goodImages {
+				nc.Spec.GaudiScaleOut.Image = image
+
+				Expect(nc.ValidateCreate()).Error().To(BeNil(), "image: %s", image)
+			}
+
+			badImages := []string{
+				"Intel/Linkdiscovery",
+				"intel/linkdiscovery:",
+				"intel//linkdiscovery",
+				"intel/linkdiscovery latest",
+			}
+
+			for _, image := range badImages {
+				nc.Spec.GaudiScaleOut.Image = image
+
+				Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidImageError{}), "image: %s", image)
+			}
+
+			nc.Spec.GaudiScaleOut.Image = ""
+			nc.Spec.GaudiScaleOut.MTU = 9100
+
+			_, err := nc.ValidateCreate()
+			Expect(err).To(BeAssignableToTypeOf(invalidMTUError{}))
+			Expect(err.Error()).To(ContainSubstring("spec.gaudiScaleOut.mtu"))
+		})
+
+		It("Should reject L3 only settings in L2 mode", func() {
+			minReady := intstr.FromString("50%")
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer:         "L2",
+						MinReadyPorts: &minReady,
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidLayerSettingsError{}))
+
+			nc.Spec.GaudiScaleOut.MinReadyPorts = nil
+			nc.Spec.GaudiScaleOut.Verification = &VerificationSpec{}
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidLayerSettingsError{}))
+
+			nc.Spec.GaudiScaleOut.Layer = "L3"
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+		})
+
+		It("Should name the field of an unknown configuration type", func() {
+			nc := NetworkClusterPolicy{}
+			nc.Spec.NodeSelector = map[string]string{
+				"foo": "bar",
+			}
+			nc.Spec.ConfigurationType = "host-nic"
+
+			_, err := nc.ValidateCreate()
+			Expect(err).To(BeAssignableToTypeOf(unknownConfigurationError{}))
+			Expect(err.Error()).To(ContainSubstring("spec.configurationType"))
+		})
+
+		It("Should keep the configuration type immutable and warn about risky updates", func() {
+			nc := NetworkClusterPolicy{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "test",
+				},
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			nc2 := nc.DeepCopy()
+			nc2.Spec.LogLevel = 3
+
+			Expect(nc2.ValidateUpdate(&nc)).To(BeEmpty())
+
+			nc2.Spec.GaudiScaleOut.Layer = "L2"
+			nc2.Spec.GaudiScaleOut.MTU = 8000
+
+			warnings, err := nc2.ValidateUpdate(&nc)
+			Expect(err).To(BeNil())
+			Expect(warnings).To(HaveLen(2))
+			Expect(warnings[0]).To(ContainSubstring("from L3 to L2"))
+			Expect(warnings[1]).To(ContainSubstring("to 8000"))
+
+			// only switching from L3 to L2 is risky
+			warnings, err = validateSpecUpdate(nc.Spec, nc2.Spec)
+			Expect(err).To(BeNil())
+			Expect(warnings).To(ConsistOf(ContainSubstring("MTU")))
+
+			nc2.Spec.ConfigurationType = "host-nic"
+
+			Expect(validateSpecUpdate(nc2.Spec, nc.Spec)).Error().To(BeAssignableToTypeOf(immutableFieldError{}))
+		})
+
+		It("Should validate the rollout settings", func() {
+			maxUnavailable := intstr.FromString("0%")
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+					Rollout: &RolloutSpec{
+						MaxUnavailable: &maxUnavailable,
+					},
+				},
+			}
+
+			_, err := nc.ValidateCreate()
+			Expect(err).To(BeAssignableToTypeOf(invalidRolloutError{}))
+			Expect(err.Error()).To(ContainSubstring("spec.rollout.maxUnavailable"))
+
+			maxUnavailable = intstr.FromString("25%")
+			nc.Spec.Rollout.Canary = &CanarySpec{}
+
+			_, err = nc.ValidateCreate()
+			Expect(err).To(BeAssignableToTypeOf(invalidRolloutError{}))
+			Expect(err.Error()).To(ContainSubstring("spec.rollout.canary"))
+
+			nc.Spec.Rollout.Canary.NodeSelector = map[string]string{"rack": "a b"}
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidRolloutError{}))
+
+			nc.Spec.Rollout.Canary.NodeSelector = map[string]string{"rack": "a1"}
+			nc.Spec.Rollout.Canary.Percentage = 10
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+		})
+
+		It("Should warn about updates while the rollout is paused", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+				},
+			}
+
+			nc2 := nc.DeepCopy()
+			nc2.Spec.Rollout = &RolloutSpec{Paused: true}
+
+			Expect(nc2.ValidateUpdate(&nc)).To(BeEmpty())
+
+			nc2.Spec.LogLevel = 3
+
+			warnings, err := nc2.ValidateUpdate(&nc)
+			Expect(err).To(BeNil())
+			Expect(warnings).To(ConsistOf(ContainSubstring("rollout is paused")))
+		})
+
+		It("Should validate the readiness taint key", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+					},
+					NodeSelector: map[string]string{
+						"foo": "bar",
+					},
+					ReadinessTaint: &ReadinessTaintSpec{},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.ReadinessTaint.Key = "example.com/scale-out not ready"
+
+			_, err := nc.ValidateCreate()
+			Expect(err).To(BeAssignableToTypeOf(invalidReadinessTaintError{}))
+			Expect(err.Error()).To(ContainSubstring("spec.readinessTaint.key"))
+
+			nc.Spec.ReadinessTaint.Key = "example.com/scale-out-not-ready"
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+		})
+
+		It("Should validate the node feature rule settings", func() {
+			nc := NetworkClusterPolicy{
+				Spec: NetworkClusterPolicySpec{
+					ConfigurationType: gaudiScaleOut,
+					GaudiScaleOut: GaudiScaleOutSpec{
+						Layer: "L3",
+						NodeFeatureRule: &NodeFeatureRuleSpec{
+							DeviceIDs:  []string{"1070"},
+							ReadyLabel: "example.com/gaudi-ready",
+						},
+					},
+					NodeSelector: map[string]string{
+						"example.com/gaudi-ready": "true",
+					},
+				},
+			}
+
+			Expect(nc.ValidateCreate()).Error().To(BeNil())
+
+			nc.Spec.GaudiScaleOut.NodeFeatureRule.DeviceIDs = []string{"1070", "0x1080"}
+
+			_, err := nc.ValidateCreate()
+			Expect(err).To(BeAssignableToTypeOf(invalidNodeFeatureRuleError{}))
+			Expect(err.Error()).To(ContainSubstring("spec.gaudiScaleOut.nodeFeatureRule.deviceIDs[1]"))
+
+			nc.Spec.GaudiScaleOut.NodeFeatureRule.DeviceIDs = nil
+			nc.Spec.GaudiScaleOut.NodeFeatureRule.DeviceLabel = "example.com/gaudi ready"
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidNodeFeatureRuleError{}))
+
+			nc.Spec.GaudiScaleOut.NodeFeatureRule.DeviceLabel = "example.com/gaudi-ready"
+
+			Expect(nc.ValidateCreate()).Error().To(BeAssignableToTypeOf(invalidNodeFeatureRuleError{}))
+		})
+
 		It("Should always accept delete", func() {
 			nc := NetworkClusterPolicy{
 				Spec: NetworkClusterPolicySpec{
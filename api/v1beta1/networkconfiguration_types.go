@@ -0,0 +1,297 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+
+// NetworkClusterPolicySpec defines the desired state of NetworkClusterPolicy
+type NetworkClusterPolicySpec struct {
+	// Configuration type that the operator will configure to the nodes. Possible options: gaudi-so.
+	// TODO: plausible other options: host-nic
+	// +kubebuilder:validation:Enum=gaudi-so
+	ConfigurationType string `json:"configurationType"`
+
+	// Select which nodes the operator should target. Align with labels created by NFD.
+	// +kubebuilder:validation:items:MinItems=1
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Label selector for the targeted nodes, combined with nodeSelector. Allows set-based
+	// requirements, for example to leave out some racks. At least one of nodeSelector and
+	// nodeLabelSelector is required.
+	NodeLabelSelector *metav1.LabelSelector `json:"nodeLabelSelector,omitempty"`
+
+	// Gaudi Scale-Out specific settings. Only valid when configuration type is 'gaudi-so'
+	GaudiScaleOut GaudiScaleOutSpec `json:"gaudiScaleOut,omitempty"`
+}
+
+// ReadinessTaintSpec defines the NoSchedule taint of the nodes that are not ready
+type ReadinessTaintSpec struct {
+	// Key of the taint. Defaults to intel.com/scale-out-not-ready.
+	// +kubebuilder:validation:MaxLength=316
+	Key string `json:"key,omitempty"`
+}
+
+// RolloutSpec defines how the changes of the policy are rolled out to the nodes
+type RolloutSpec struct {
+	// Maximum number or percentage of targeted nodes that can be unavailable during the
+	// rollout. Defaults to 1.
+	// +kubebuilder:validation:XIntOrString
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// Stop replacing the node agent pods. The nodes keep their current configuration until
+	// the rollout is resumed.
+	Paused bool `json:"paused,omitempty"`
+
+	// Nodes updated first. The rest of the nodes are updated once all the canary nodes are
+	// ready, and the rollout halts if any of them fails.
+	Canary *CanarySpec `json:"canary,omitempty"`
+}
+
+// CanarySpec defines the nodes updated first in a rollout
+type CanarySpec struct {
+	// Select the canary nodes among the targeted nodes.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Percentage of the targeted nodes, or of the nodes matching the node selector, used as
+	// canary nodes. The nodes are picked in name order. Defaults to 100 with a node selector.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Percentage int32 `json:"percentage,omitempty"`
+}
+
+// PodTemplateOverrides defines the settings merged into the pod template of the node agent
+type PodTemplateOverrides struct {
+	// Additional annotations for the pods.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Additional labels for the pods. The 'app' label is used by the operator and cannot be set.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Tolerations for the pods, for example to run on nodes tainted for accelerator workloads.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the pods.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Resource requests and limits replacing the defaults of the container.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Priority class of the pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Additional environment variables for the container. NODE_NAME is set by the operator and
+	// cannot be overridden.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// GaudiScaleOutSpec defines the desired state of GaudiScaleOut
+type GaudiScaleOutSpec struct {
+	// Disable Gaudi scale-out interfaces in NetworkManager. For nodes where NetworkManager tries
+	// to configure the Gaudi interfaces, prevent it from doing so.
+	DisableNetworkManager bool `json:"disableNetworkManager,omitempty"`
+
+	// Layer where the configuration should occur. Possible options: L2 and L3.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=L2;L3
+	Layer string `json:"layer,omitempty"`
+
+	// Container image to handle interface configurations on the worker nodes.
+	Image string `json:"image,omitempty"`
+
+	// Normal image pull policy used in the resulting daemonset.
+	// +kubebuilder:validation:Enum=Never;Always;IfNotPresent
+	PullPolicy string `json:"pullPolicy,omitempty"`
+
+	// Secrets for pulling the image from a private registry. The secrets have to exist in the
+	// operator namespace.
+	// +listType=map
+	// +listMapKey=name
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// MTU for the scale-out interfaces.
+	// +kubebuilder:validation:Minimum=1500
+	// +kubebuilder:validation:Maximum=9000
+	MTU int `json:"mtu,omitempty"`
+
+	// Apply policy for the interface configurations. With 'strict' any failure rolls the node
+	// back to its original network state, with 'best-effort' the interfaces that could be
+	// configured are kept.
+	// +kubebuilder:validation:Enum=strict;best-effort
+	ApplyPolicy string `json:"applyPolicy,omitempty"`
+
+	// Minimum number or percentage of configured scale-out ports for the node to be labeled
+	// ready in L3 mode. Defaults to all ports.
+	// +kubebuilder:validation:XIntOrString
+	// +kubebuilder:validation:Pattern="^(100|[1-9]?[0-9])%$"
+	MinReadyPorts *intstr.IntOrString `json:"minReadyPorts,omitempty"`
+
+	// Connectivity verification after the configuration in L3 mode. When set, only the ports
+	// reaching their LLDP peer and the targets count towards the node readiness. The ports
+	// are verified with ICMP echo requests, the LLDP peer and the targets must answer them.
+	Verification *VerificationSpec `json:"verification,omitempty"`
+
+	// Persistent configuration backend. With 'systemd-networkd' the interface configuration
+	// is also written as networkd files, with 'NetworkManager' as NetworkManager connection
+	// profiles and with 'netplan' as a netplan file, so that it is applied at boot before the
+	// pod starts. The netplan file is checked with 'netplan generate' through the netplan D-Bus
+	// service of the node, it is not applied before the next boot. Defaults to 'none'.
+	// 'NetworkManager' and 'netplan' are only supported in L3 mode.
+	// +kubebuilder:validation:Enum=none;systemd-networkd;NetworkManager;netplan
+	Persistence string `json:"persistence,omitempty"`
+
+	// Node feature rule labeling the Gaudi nodes. The operator manages the rule when Node
+	// Feature Discovery is installed, these settings extend its defaults.
+	NodeFeatureRule *NodeFeatureRuleSpec `json:"nodeFeatureRule,omitempty"`
+
+	// Log level of the discovery agent on the nodes.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=8
+	LogLevel int `json:"logLevel,omitempty"`
+
+	// Overrides for the pod template of the node agent DaemonSet.
+	PodTemplate *PodTemplateOverrides `json:"podTemplate,omitempty"`
+
+	// Rollout of the changes to the targeted nodes. Without it the node agent pods are
+	// replaced one node at a time.
+	Rollout *RolloutSpec `json:"rollout,omitempty"`
+
+	// Taint the targeted nodes until their scale-out network is ready, so that no workloads
+	// are scheduled to them before. The node agent tolerates the taint.
+	ReadinessTaint *ReadinessTaintSpec `json:"readinessTaint,omitempty"`
+}
+
+// NodeFeatureRuleSpec defines the detection of the Gaudi nodes
+type NodeFeatureRuleSpec struct {
+	// Additional PCI device IDs of Gaudi accelerators, as four hexadecimal digits.
+	// +kubebuilder:validation:MaxItems=16
+	// +kubebuilder:validation:items:Pattern="^[0-9a-f]{4}$"
+	DeviceIDs []string `json:"deviceIDs,omitempty"`
+
+	// Label of the nodes with Gaudi accelerators. Defaults to
+	// intel.feature.node.kubernetes.io/gaudi.
+	DeviceLabel string `json:"deviceLabel,omitempty"`
+
+	// Label of the nodes with Gaudi accelerators and their driver loaded. Defaults to
+	// intel.feature.node.kubernetes.io/gaudi-ready.
+	ReadyLabel string `json:"readyLabel,omitempty"`
+}
+
+// VerificationSpec defines the connectivity verification of the scale-out ports.
+// A port is verified when the LLDP peer and every target answer ICMP echo requests
+// sent through it, switches filtering ICMP fail the verification.
+type VerificationSpec struct {
+	// Time to wait for a reply from a target. Defaults to 1s.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Number of attempts before a target is considered unreachable. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	Attempts int `json:"attempts,omitempty"`
+
+	// Additional IPv4 addresses in the routed scale-out network to reach through every port.
+	// +kubebuilder:validation:MaxItems=16
+	Targets []string `json:"targets,omitempty"`
+}
+
+// NetworkClusterPolicyStatus defines the observed state of NetworkClusterPolicy
+type NetworkClusterPolicyStatus struct {
+	// Number of nodes targeted by the policy.
+	Targets int32 `json:"targets"`
+
+	// Number of targeted nodes where the configuration is ready.
+	ReadyNodes int32 `json:"ready"`
+
+	// Overall state of the policy.
+	State string `json:"state"`
+
+	// Problems found with the policy or on the targeted nodes.
+	Errors []PolicyError `json:"errors,omitempty"`
+
+	// Progress of the rollout of the node agent.
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+}
+
+// PolicyError describes a problem with the policy
+type PolicyError struct {
+	// Node where the problem is, empty for problems of the whole policy.
+	Node string `json:"node,omitempty"`
+
+	// Description of the problem.
+	Message string `json:"message"`
+}
+
+// RolloutStatus defines the observed progress of a rollout
+type RolloutStatus struct {
+	// Revision of the node agent DaemonSet being rolled out.
+	Revision int64 `json:"revision"`
+
+	// Phase of the rollout: Progressing, Paused, Halted or Complete.
+	Phase string `json:"phase"`
+
+	// Number of canary nodes of the rollout.
+	CanaryNodes int32 `json:"canaryNodes,omitempty"`
+
+	// Generation of the policy the rollout halted at. A halted rollout stays halted, also
+	// when the failed canary nodes recover, until the policy or the revision changes.
+	HaltedGeneration int64 `json:"haltedGeneration,omitempty"`
+
+	// Number of nodes running each revision, the newest first.
+	Revisions []RevisionStatus `json:"revisions,omitempty"`
+}
+
+// RevisionStatus defines the nodes running a revision of the node agent
+type RevisionStatus struct {
+	// Revision of the node agent DaemonSet.
+	Revision int64 `json:"revision"`
+
+	// Number of nodes running the revision.
+	Nodes int32 `json:"nodes"`
+
+	// Number of those nodes that are ready.
+	ReadyNodes int32 `json:"ready"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=networkclusterpolicies,scope=Cluster
+//+kubebuilder:subresource:status
+//+kubebuilder:storageversion
+
+// NetworkClusterPolicy is the Schema for the networkclusterpolicies API
+type NetworkClusterPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NetworkClusterPolicySpec   `json:"spec,omitempty"`
+	Status NetworkClusterPolicyStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NetworkClusterPolicyList contains a list of NetworkClusterPolicy
+type NetworkClusterPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NetworkClusterPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NetworkClusterPolicy{}, &NetworkClusterPolicyList{})
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// heartbeatInterval is how often the idle loop reports that it is alive.
+const heartbeatInterval = 10 * time.Second
+
+// healthState backs the readiness and liveness endpoints. Readiness is set
+// once the interfaces are configured and verified, liveness follows the
+// heartbeats of the idle loop.
+type healthState struct {
+	ready     atomic.Bool
+	lastBeat  atomic.Int64
+	now       func() time.Time
+	threshold time.Duration
+}
+
+func newHealthState() *healthState {
+	return &healthState{
+		now:       time.Now,
+		threshold: 3 * heartbeatInterval,
+	}
+}
+
+func (h *healthState) setReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+func (h *healthState) heartbeat() {
+	h.lastBeat.Store(h.now().UnixNano())
+}
+
+// alive is true while configuring, which has its own timeouts, and after
+// that as long as the idle loop keeps beating.
+func (h *healthState) alive() bool {
+	last := h.lastBeat.Load()
+	if last == 0 {
+		return true
+	}
+
+	return h.now().Sub(time.Unix(0, last)) < h.threshold
+}
+
+func healthHandler(check func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if !check() {
+			http.Error(w, "not ok", http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+func (h *healthState) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/readyz", healthHandler(h.ready.Load))
+	mux.Handle("/livez", healthHandler(h.alive))
+
+	return mux
+}
+
+// serveHTTP serves the handler on the given address until the context is
+// done.
+func serveHTTP(ctx context.Context, name, addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	go func() {
+		klog.Infof("Serving %s on %s", name, addr)
+
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.Errorf("Failed to serve %s: %v", name, err)
+		}
+	}()
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func probe(t *testing.T, handler http.Handler, path string) int {
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return recorder.Code
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	now := time.Now()
+
+	health := newHealthState()
+	health.now = func() time.Time { return now }
+	handler := health.handler()
+
+	if code := probe(t, handler, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("should not be ready before configuration, got %d", code)
+	}
+	if code := probe(t, handler, "/livez"); code != http.StatusOK {
+		t.Errorf("should be alive while configuring, got %d", code)
+	}
+
+	health.setReady(true)
+	health.heartbeat()
+
+	if code := probe(t, handler, "/readyz"); code != http.StatusOK {
+		t.Errorf("should be ready after configuration, got %d", code)
+	}
+
+	now = now.Add(heartbeatInterval)
+	if code := probe(t, handler, "/livez"); code != http.StatusOK {
+		t.Errorf("should be alive after one missed heartbeat, got %d", code)
+	}
+
+	now = now.Add(3 * heartbeatInterval)
+	if code := probe(t, handler, "/livez"); code != http.StatusServiceUnavailable {
+		t.Errorf("should not be alive without heartbeats, got %d", code)
+	}
+
+	health.setReady(false)
+	if code := probe(t, handler, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("should not be ready after termination, got %d", code)
+	}
+}
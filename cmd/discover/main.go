@@ -18,17 +18,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	goflag "flag"
 	"fmt"
+	"maps"
+	"math/big"
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/klog/v2"
 
 	"github.com/intel/network-operator/pkg/lldp"
@@ -40,9 +46,16 @@ const (
 	L2 = "L2"
 	L3 = "L3"
 
+	applyStrict     = "strict"
+	applyBestEffort = "best-effort"
+
 	nfdFeatureDir         = "/etc/kubernetes/node-feature-discovery/features.d/"
 	nfdLabelFile          = nfdFeatureDir + "scale-out-readiness.txt"
 	nfdScaleOutReadyLabel = "intel.feature.node.kubernetes.io/gaudi-scale-out=true"
+
+	nfdScaleOutPortsLabel      = "intel.feature.node.kubernetes.io/gaudi-scale-out-ports"
+	nfdScaleOutPortsTotalLabel = "intel.feature.node.kubernetes.io/gaudi-scale-out-ports-total"
+	nfdScaleOutPortsMaskLabel  = "intel.feature.node.kubernetes.io/gaudi-scale-out-ports-mask"
 )
 
 type cmdConfig struct {
@@ -55,7 +68,22 @@ type cmdConfig struct {
 	mode         string
 	keepRunning  bool
 	networkd     string
+	netplan      string
+	nmProfiles   bool
+	nmConfDir    string
 	mtu          int
+	stateFile    string
+	applyPolicy  string
+	minReady     string
+
+	verify         bool
+	verifyTimeout  time.Duration
+	verifyAttempts int
+	verifyTargets  string
+
+	metricsAddr     string
+	monitorInterval time.Duration
+	healthAddr      string
 }
 
 func sanitizeInput(config *cmdConfig) error {
@@ -78,6 +106,46 @@ func sanitizeInput(config *cmdConfig) error {
 		return fmt.Errorf("Invalid mode '%s'", config.mode)
 	}
 
+	switch strings.ToLower(config.applyPolicy) {
+	case applyStrict:
+		config.applyPolicy = applyStrict
+	case applyBestEffort:
+		config.applyPolicy = applyBestEffort
+	default:
+		return fmt.Errorf("Invalid apply policy '%s'", config.applyPolicy)
+	}
+
+	minReady := intstr.Parse(config.minReady)
+	if v, err := intstr.GetScaledValueFromIntOrPercent(&minReady, 100, true); err != nil || v < 0 {
+		return fmt.Errorf("Invalid minimum ready ports '%s'", config.minReady)
+	}
+
+	if config.nmProfiles && config.disableNM {
+		return fmt.Errorf("NetworkManager profiles cannot be used with NetworkManager disabled")
+	}
+
+	if config.monitorInterval <= 0 {
+		config.monitorInterval = time.Second * 30
+	}
+
+	if config.verify {
+		if config.verifyAttempts < 1 {
+			config.verifyAttempts = 1
+		}
+
+		if config.verifyTimeout <= 0 {
+			return fmt.Errorf("Invalid verification timeout %s", config.verifyTimeout)
+		}
+
+		if config.verifyTargets != "" {
+			for _, t := range strings.Split(config.verifyTargets, ",") {
+				if ip := net.ParseIP(strings.TrimSpace(t)); ip == nil || ip.To4() == nil {
+					return fmt.Errorf("Invalid verification target '%s'", t)
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -137,51 +205,93 @@ func preCleanups(config *cmdConfig) error {
 		klog.Infof("Created systemd-networkd directory %s", config.networkd)
 	}
 
+	if config.netplan != "" {
+		if err := os.MkdirAll(config.netplan, 0755); err != nil {
+			return fmt.Errorf("Cannot create netplan directory: %v", err)
+		}
+	}
+
 	return nil
 }
 
-func postCleanups(networkConfigs map[string]*networkConfiguration) {
+// postCleanups runs when the agent stops, also when its pod is replaced in a
+// rollout or evicted. The applied configuration stays on the host for the
+// next agent, only the cleanup subcommand run on policy deletion reverts it.
+func postCleanups() {
 	klog.Info("Clean up before exiting...")
 
 	err := os.Remove(nfdLabelFile)
 	if err != nil {
 		klog.Warningf("Failed to remove NFD label file: %+v\n", err)
 	}
+}
 
-	klog.Infof("Restoring interfaces to original state...")
-	if err := removeExistingIPs(networkConfigs); err != nil {
-		klog.Warningf("Failed to remove any existing IPs from interfaces: %+v\n", err)
+// minReadyPorts returns the number of interfaces that need to be configured
+// for the node to be ready. With the strict apply policy all are needed.
+func minReadyPorts(config *cmdConfig, total int) int {
+	if config.applyPolicy == applyStrict {
+		return total
 	}
 
-	if err := interfacesRestoreDown(networkConfigs); err != nil {
-		klog.Warningf("Failed to restore interfaces to original state: %+v\n", err)
+	minReady := intstr.Parse(config.minReady)
+
+	v, err := intstr.GetScaledValueFromIntOrPercent(&minReady, total, true)
+	if err != nil || v > total {
+		return total
 	}
+
+	return v
 }
 
-func cmdRun(config *cmdConfig) error {
-	err := sanitizeInput(config)
-	if err != nil {
-		return err
+// portLabels returns the NFD labels with the number of configured ports and
+// a hex bitmask of them, bit 0 being the first interface in name order.
+func portLabels(networkConfigs map[string]*networkConfiguration) string {
+	ifnames := slices.Sorted(maps.Keys(networkConfigs))
+	mask := new(big.Int)
+	configured := 0
+
+	for i, ifname := range ifnames {
+		if networkConfigs[ifname].ready() {
+			mask.SetBit(mask, i, 1)
+			configured++
+		}
 	}
 
-	if err := preCleanups(config); err != nil {
-		return fmt.Errorf("Failed to pre-cleanup: %v", err)
+	return fmt.Sprintf("%s=%d\n%s=%d\n%s=%s\n",
+		nfdScaleOutPortsLabel, configured,
+		nfdScaleOutPortsTotalLabel, len(ifnames),
+		nfdScaleOutPortsMaskLabel, mask.Text(16))
+}
+
+// readHostState returns the host state journal from a previous run, or a
+// new one if there is none. Interfaces not yet in the journal are added
+// with their current state.
+func readHostState(config *cmdConfig, networkConfigs map[string]*networkConfiguration) *hostState {
+	if config.stateFile == "" {
+		return newHostState(networkConfigs)
 	}
 
-	allInterfaces := getNetworks()
+	state, err := loadHostState(config.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return newHostState(networkConfigs)
+	} else if err != nil {
+		klog.Warningf("Ignoring host state, current state is used as original: %v", err)
 
-	if len(config.ifaces) > 0 {
-		allInterfaces = append(allInterfaces, strings.Split(config.ifaces, ",")...)
+		return newHostState(networkConfigs)
 	}
 
-	if len(allInterfaces) == 0 {
-		return fmt.Errorf("No interfaces found")
-	}
+	klog.Infof("Using original interface state from '%s'", config.stateFile)
 
-	networkConfigs := getNetworkConfigs(allInterfaces)
-	if len(networkConfigs) < len(allInterfaces) {
-		return fmt.Errorf("Not all interfaces were found in the system")
-	}
+	state.addInterfaces(networkConfigs)
+
+	return state
+}
+
+// applyConfiguration does the host changes. With the strict apply policy any
+// failure is returned so that the caller can roll back, with best-effort the
+// failures of individual interfaces are only logged.
+func applyConfiguration(config *cmdConfig, allInterfaces []string, networkConfigs map[string]*networkConfiguration, state *hostState) error {
+	strict := config.applyPolicy == applyStrict
 
 	if config.disableNM {
 		nmapi, err := nm.NewNetworkManager()
@@ -189,56 +299,302 @@ func cmdRun(config *cmdConfig) error {
 			return fmt.Errorf("Failed to create NetworkManager: %v", err)
 		}
 
+		managed, err := nm.GetManagedInterfaces(nmapi, allInterfaces)
+		if err != nil {
+			return fmt.Errorf("Failed to read NetworkManager state for interfaces: %v", err)
+		}
+
+		state.setNMManaged(managed)
+
 		err = nm.DisableNetworkManagerForInterfaces(nmapi, allInterfaces)
 		if err != nil {
 			return fmt.Errorf("Failed to disable interfaces in NetworkManager: %v", err)
 		}
+
+		// the runtime setting is lost when NetworkManager restarts
+		hwaddrs := []net.HardwareAddr{}
+		for _, nwconfig := range networkConfigs {
+			hwaddrs = append(hwaddrs, nwconfig.link.Attrs().HardwareAddr)
+		}
+
+		state.addFiles(filepath.Join(config.nmConfDir, nm.UnmanagedDevicesConfFile))
+
+		if _, err := nm.WriteUnmanagedDevicesConfig(config.nmConfDir, hwaddrs); err != nil {
+			if strict {
+				return err
+			}
+			klog.Warningf("Interfaces will be managed by NetworkManager after its restart: %v", err)
+		}
+	}
+
+	if err := saveHostState(config.stateFile, state); err != nil {
+		if strict {
+			return fmt.Errorf("Failed to save host state: %v", err)
+		}
+		klog.Warningf("Failed to save host state, cleanup will not be possible: %v", err)
 	}
 
 	if err := interfacesUp(networkConfigs); err != nil {
 		return err
 	}
 
-	interfacesSetMTU(networkConfigs, config.mtu)
+	if err := interfacesSetMTU(networkConfigs, config.mtu); err != nil && strict {
+		return err
+	}
+
+	// addresses applied on a previous run are kept in place until LLDP
+	// tells whether they are still valid
+	keepAddrs := map[string][]string{}
+	if config.mode == L3 && config.configure {
+		keepAddrs = state.appliedAddresses()
+	}
 
-	if err := removeExistingIPs(networkConfigs); err != nil {
+	if err := removeIPsExcept(networkConfigs, keepAddrs); err != nil {
 		return fmt.Errorf("Failed to remove any existing IPs from interfaces: %+v", err)
 	}
 
-	if config.mode == L3 {
-		detectLLDP(config, networkConfigs)
-		foundpeers := lldpResults(networkConfigs)
+	if config.mode != L3 {
+		for _, nwconfig := range networkConfigs {
+			nwconfig.configured = nwconfig.link.Attrs().Flags&net.FlagUp != 0
+		}
+
+		if config.networkd != "" {
+			if err := persistNetworkd(config, networkConfigs, state); err != nil {
+				return err
+			}
 
-		if config.configure && foundpeers {
-			numConfigured, numTotal := configureInterfaces(networkConfigs)
-			if numConfigured < numTotal {
-				return fmt.Errorf("Not all interfaces were configured (%d/%d).", numConfigured, numTotal)
+			if err := saveHostState(config.stateFile, state); err != nil {
+				klog.Warningf("Failed to save host state, cleanup will not be complete: %v", err)
 			}
+		}
+
+		return nil
+	}
+
+	detectLLDP(config, networkConfigs)
+	foundpeers := lldpResults(networkConfigs)
+
+	if !config.configure {
+		return nil
+	}
+
+	if strict {
+		if err := checkPlannedAddresses(networkConfigs); err != nil {
+			return err
+		}
+	}
+
+	var configErr error
+
+	if foundpeers {
+		numConfigured, numTotal := configureInterfaces(networkConfigs)
+		numRequired := minReadyPorts(config, numTotal)
+
+		if strict && numConfigured < numTotal {
+			configErr = fmt.Errorf("Not all interfaces were configured (%d/%d).", numConfigured, numTotal)
+		} else if numConfigured < numRequired {
+			configErr = fmt.Errorf("Not enough interfaces were configured (%d/%d, %d required).",
+				numConfigured, numTotal, numRequired)
+		} else {
 			klog.Infof("Configured %d of %d interfaces\n", numConfigured, numTotal)
 		}
+	}
+
+	// drop addresses from previous runs that LLDP no longer agrees with
+	if err := removeIPsExcept(networkConfigs, configuredAddresses(networkConfigs)); err != nil {
+		if strict {
+			return fmt.Errorf("Failed to remove stale IPs from interfaces: %v", err)
+		}
+		klog.Warningf("Failed to remove stale IPs from interfaces: %v", err)
+	}
+
+	state.setApplied(networkConfigs, config.mtu)
+	if err := saveHostState(config.stateFile, state); err != nil {
+		klog.Warningf("Failed to save host state, cleanup will not be complete: %v", err)
+	}
 
-		if config.gaudinetfile != "" {
-			if err := WriteGaudiNet(config.gaudinetfile, networkConfigs); err != nil {
-				klog.Errorf("Error: %v\n", err)
+	if configErr != nil {
+		return configErr
+	}
+
+	if config.gaudinetfile != "" {
+		// record the file before writing it so that a partial write is
+		// cleaned up as well
+		state.addFiles(config.gaudinetfile)
+
+		if err := WriteGaudiNet(config.gaudinetfile, networkConfigs); err != nil {
+			if strict {
+				return fmt.Errorf("Could not write gaudinet file: %v", err)
 			}
+			klog.Errorf("Error: %v\n", err)
 		}
+	}
 
-		if config.networkd != "" {
-			if _, err = WriteSystemdNetworkd(config.networkd, networkConfigs); err != nil {
-				return fmt.Errorf("Could not create systemd-networkd configuration files: %v\n", err)
+	if config.networkd != "" {
+		if err := persistNetworkd(config, networkConfigs, state); err != nil {
+			return err
+		}
+	}
+
+	if config.netplan != "" {
+		if err := persistNetplan(config, networkConfigs, state); err != nil {
+			if strict {
+				return err
 			}
+			klog.Errorf("Error: %v\n", err)
 		}
 	}
 
+	if config.nmProfiles {
+		configured, err := writeConnectionProfiles(networkConfigs, config.mtu)
+
+		state.addConnections(configured...)
+
+		if err != nil {
+			if strict {
+				return fmt.Errorf("Could not create NetworkManager connection profiles: %v", err)
+			}
+			klog.Errorf("Error: Could not create NetworkManager connection profiles: %v\n", err)
+		}
+	}
+
+	if err := saveHostState(config.stateFile, state); err != nil {
+		klog.Warningf("Failed to save host state, cleanup will not be complete: %v", err)
+	}
+
+	return nil
+}
+
+// persistNetworkd writes the systemd-networkd files for the configured
+// interfaces, removes the ones of interfaces no longer configured and makes
+// systemd-networkd reload them if anything changed.
+func persistNetworkd(config *cmdConfig, networkConfigs map[string]*networkConfiguration, state *hostState) error {
+	configured, changed, err := WriteSystemdNetworkd(config.networkd, networkConfigs, config.mode, config.mtu)
+
+	for _, ifname := range configured {
+		state.addFiles(networkdFilename(config.networkd, ifname))
+	}
+
+	if err != nil {
+		return fmt.Errorf("Could not create systemd-networkd configuration files: %v\n", err)
+	}
+
+	pruned, err := PruneSystemdNetworkd(config.networkd, configured)
+	if err != nil {
+		klog.Warningf("Failed to remove stale systemd-networkd configuration files: %v", err)
+	}
+
+	if changed || pruned {
+		if err := networkdReload(); err != nil {
+			klog.Warningf("Failed to reload systemd-networkd: %v", err)
+		} else {
+			klog.Infof("Reloaded systemd-networkd configuration")
+		}
+	}
+
+	return nil
+}
+
+// rollbackConfiguration restores the host to the original state recorded in
+// the journal after a failed strict apply.
+func rollbackConfiguration(config *cmdConfig, state *hostState, applyErr error) error {
+	klog.Warningf("Apply failed, rolling back to original state: %v", applyErr)
+
+	if err := restoreHostState(state); err != nil {
+		return fmt.Errorf("%v; rollback failed: %v", applyErr, err)
+	}
+
+	if config.stateFile != "" {
+		if err := os.Remove(config.stateFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			klog.Warningf("Failed to remove host state file: %v", err)
+		}
+	}
+
+	klog.Infof("Rolled back to original state")
+
+	return applyErr
+}
+
+// verifyConfiguration checks that enough of the configured interfaces reach
+// their targets. A failed verification is rolled back in strict mode like the
+// other apply errors.
+func verifyConfiguration(config *cmdConfig, networkConfigs map[string]*networkConfiguration, state *hostState) error {
+	reachable, total := verifyConnectivity(config, networkConfigs), len(networkConfigs)
+
+	required := minReadyPorts(config, total)
+	if reachable >= required {
+		return nil
+	}
+
+	err := fmt.Errorf("Not enough interfaces passed verification (%d/%d, %d required).",
+		reachable, total, required)
+
+	if config.applyPolicy == applyStrict {
+		return rollbackConfiguration(config, state, err)
+	}
+
+	return err
+}
+
+func cmdRun(config *cmdConfig) error {
+	err := sanitizeInput(config)
+	if err != nil {
+		return err
+	}
+
+	if err := preCleanups(config); err != nil {
+		return fmt.Errorf("Failed to pre-cleanup: %v", err)
+	}
+
+	health := newHealthState()
+
+	if config.healthAddr != "" {
+		ctx, cancel := context.WithCancel(config.ctx)
+		defer cancel()
+
+		serveHTTP(ctx, "health probes", config.healthAddr, health.handler())
+	}
+
+	allInterfaces := getNetworks()
+
+	if len(config.ifaces) > 0 {
+		allInterfaces = append(allInterfaces, strings.Split(config.ifaces, ",")...)
+	}
+
+	if len(allInterfaces) == 0 {
+		return fmt.Errorf("No interfaces found")
+	}
+
+	networkConfigs := getNetworkConfigs(allInterfaces)
+	if len(networkConfigs) < len(allInterfaces) {
+		return fmt.Errorf("Not all interfaces were found in the system")
+	}
+
+	state := readHostState(config, networkConfigs)
+
+	if err := applyConfiguration(config, allInterfaces, networkConfigs, state); err != nil {
+		if config.applyPolicy == applyStrict {
+			return rollbackConfiguration(config, state, err)
+		}
+
+		return err
+	}
+
 	logResults(config, networkConfigs)
 
+	if config.configure && config.mode == L3 && config.verify {
+		if err := verifyConfiguration(config, networkConfigs, state); err != nil {
+			return err
+		}
+	}
+
 	if !config.configure {
 		if err := interfacesRestoreDown(networkConfigs); err != nil {
 			return err
 		}
 	} else if config.configure && config.keepRunning {
 		if s, err := os.Stat(nfdFeatureDir); err == nil && s.IsDir() {
-			content := nfdScaleOutReadyLabel + "\n"
+			content := nfdScaleOutReadyLabel + "\n" + portLabels(networkConfigs)
 
 			if err := os.WriteFile(nfdLabelFile, []byte(content), 0644); err != nil {
 				return fmt.Errorf("Failed to write NFD label to indicate scale-out readiness: %+v\n", err)
@@ -247,14 +603,69 @@ func cmdRun(config *cmdConfig) error {
 
 		klog.Infof("Configurations done. Idling...")
 
-		defer postCleanups(networkConfigs)
+		health.setReady(true)
+
+		defer postCleanups()
+
+		if config.metricsAddr != "" {
+			ctx, cancel := context.WithCancel(config.ctx)
+			defer cancel()
+
+			monitor := newPortMonitor(networkConfigs)
+
+			startMetricsServer(ctx, config.metricsAddr, monitor)
+			go monitor.run(ctx, config)
+		}
 
 		term := make(chan os.Signal, 1)
 
 		signal.Notify(term, os.Interrupt, syscall.SIGTERM)
-		<-term
+
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			health.heartbeat()
+
+			select {
+			case <-term:
+				health.setReady(false)
+
+				return nil
+			case <-ticker.C:
+			}
+		}
+	}
+
+	return nil
+}
+
+func cmdCleanup(config *cmdConfig) error {
+	if err := os.Remove(nfdLabelFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		klog.Warningf("Failed to remove NFD label file: %+v\n", err)
+	}
+
+	state, err := loadHostState(config.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		klog.Infof("No host state in '%s', nothing to clean up", config.stateFile)
+
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Failed to read host state: %v", err)
+	}
+
+	klog.Infof("Restoring host to original state...")
+
+	if err := restoreHostState(state); err != nil {
+		return fmt.Errorf("Failed to restore host state: %v", err)
 	}
 
+	if err := os.Remove(config.stateFile); err != nil {
+		return fmt.Errorf("Failed to remove host state file: %v", err)
+	}
+
+	klog.Infof("Cleanup done")
+
 	return nil
 }
 
@@ -272,10 +683,23 @@ func setupCmd() (*cobra.Command, error) {
 			return cmdRun(config)
 		},
 	}
+	cleanupCmd := &cobra.Command{
+		Use:   "cleanup",
+		Short: "Revert the host changes done by discover",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+
+			return cmdCleanup(config)
+		},
+	}
+	cmd.AddCommand(cleanupCmd)
+
 	fs := goflag.FlagSet{}
 	klog.InitFlags(&fs)
 
-	cmd.Flags().AddGoFlagSet(&fs)
+	cmd.PersistentFlags().AddGoFlagSet(&fs)
+	cmd.PersistentFlags().StringVarP(&config.stateFile, "state", "", defaultStateFile,
+		"File for recording the original host state, used by cleanup")
 	cmd.Flags().SortFlags = false
 
 	cmd.Flags().StringVarP(&config.mode, "mode", "", L3,
@@ -284,6 +708,8 @@ func setupCmd() (*cobra.Command, error) {
 		"Configure L3 network with LLDP or set interfaces up with L2 networks")
 	cmd.Flags().BoolVarP(&config.disableNM, "disable-networkmanager", "", false,
 		"Disable Host's NetworkManager for interfaces")
+	cmd.Flags().StringVarP(&config.nmConfDir, "networkmanager-conf-dir", "", "/etc/NetworkManager/conf.d",
+		"NetworkManager drop-in directory for keeping the interfaces unmanaged over restarts")
 	cmd.Flags().StringVarP(&config.ifaces, "interfaces", "", "",
 		"Comma separated list of additional network interfaces")
 	cmd.Flags().DurationVarP(&config.timeout, "wait", "", time.Second*30,
@@ -294,8 +720,30 @@ func setupCmd() (*cobra.Command, error) {
 		"Keep running after any configurations are done")
 	cmd.Flags().StringVarP(&config.networkd, "systemd-networkd", "", "",
 		"Write systemd networkd configuration files to given directory")
+	cmd.Flags().StringVarP(&config.netplan, "netplan", "", "",
+		"Write a netplan configuration file to given directory")
+	cmd.Flags().BoolVarP(&config.nmProfiles, "networkmanager-profiles", "", false,
+		"Create persistent NetworkManager connection profiles for the configured interfaces")
 	cmd.Flags().IntVarP(&config.mtu, "mtu", "", 1500,
 		"MTU value to set for interfaces")
+	cmd.Flags().StringVarP(&config.applyPolicy, "apply-policy", "", applyBestEffort,
+		"'strict' to roll back all changes on any failure or 'best-effort' to keep the interfaces that could be configured")
+	cmd.Flags().StringVarP(&config.minReady, "min-ready-ports", "", "100%",
+		"Number or percentage of configured interfaces required for readiness in L3 mode")
+	cmd.Flags().BoolVarP(&config.verify, "verify", "", false,
+		"Verify that configured interfaces reach their LLDP peer before declaring readiness")
+	cmd.Flags().DurationVarP(&config.verifyTimeout, "verify-timeout", "", time.Second,
+		"Time to wait for a reply when verifying connectivity")
+	cmd.Flags().IntVarP(&config.verifyAttempts, "verify-attempts", "", 3,
+		"Number of attempts for each verification target")
+	cmd.Flags().StringVarP(&config.verifyTargets, "verify-targets", "", "",
+		"Comma separated list of additional IPv4 addresses to reach through every interface")
+	cmd.Flags().StringVarP(&config.metricsAddr, "metrics-bind-address", "", "",
+		"Address to serve interface health metrics on while running, e.g. ':9112'. Disabled when empty")
+	cmd.Flags().DurationVarP(&config.monitorInterval, "monitor-interval", "", time.Second*30,
+		"Interval for checking the gateway reachability while running")
+	cmd.Flags().StringVarP(&config.healthAddr, "health-probe-bind-address", "", "",
+		"Address to serve the /readyz and /livez probes on, e.g. ':9113'. Disabled when empty")
 
 	return cmd, nil
 }
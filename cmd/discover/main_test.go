@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSanitizeInput(t *testing.T) {
+	config := &cmdConfig{mode: "l3", mtu: 100, applyPolicy: "Strict", minReady: "90%"}
+
+	if err := sanitizeInput(config); err != nil {
+		t.Errorf("sanitizeInput should have passed: %v", err)
+	}
+	if config.mode != L3 || config.mtu != 1500 || config.applyPolicy != applyStrict {
+		t.Errorf("unexpected sanitized config: %+v", config)
+	}
+
+	badConfigs := []cmdConfig{
+		{mode: "L4", applyPolicy: applyStrict, minReady: "1"},
+		{mode: L3, applyPolicy: "sometimes", minReady: "1"},
+		{mode: L3, applyPolicy: applyBestEffort, minReady: "foo"},
+		{mode: L3, applyPolicy: applyBestEffort, minReady: "-1"},
+		{mode: L3, applyPolicy: applyBestEffort, minReady: "1", verify: true},
+		{mode: L3, applyPolicy: applyBestEffort, minReady: "1", verify: true, verifyTimeout: time.Second, verifyTargets: "10.0.0.1,foo"},
+		{mode: L3, applyPolicy: applyBestEffort, minReady: "1", nmProfiles: true, disableNM: true},
+	}
+
+	for _, c := range badConfigs {
+		if err := sanitizeInput(&c); err == nil {
+			t.Errorf("sanitizeInput should have failed for %+v", c)
+		}
+	}
+}
+
+func TestMinReadyPorts(t *testing.T) {
+	testCases := []struct {
+		minReady string
+		total    int
+		expected int
+	}{
+		{"100%", 24, 24},
+		{"90%", 24, 22},
+		{"0%", 24, 0},
+		{"20", 24, 20},
+		{"30", 24, 24},
+		{"foo", 24, 24},
+	}
+
+	for _, tc := range testCases {
+		config := &cmdConfig{minReady: tc.minReady}
+
+		if v := minReadyPorts(config, tc.total); v != tc.expected {
+			t.Errorf("minimum ready ports for '%s' of %d is %d, expected %d", tc.minReady, tc.total, v, tc.expected)
+		}
+	}
+}
+
+func TestPortLabels(t *testing.T) {
+	nwconfigs := getFakeNetworkDataConfigs()
+	nwconfigs["eth_a"].configured = true
+	nwconfigs["eth_c"].configured = true
+
+	labels := portLabels(nwconfigs)
+
+	expected := []string{
+		nfdScaleOutPortsLabel + "=2",
+		nfdScaleOutPortsTotalLabel + "=3",
+		nfdScaleOutPortsMaskLabel + "=5",
+	}
+
+	for _, e := range expected {
+		if !strings.Contains(labels, e+"\n") {
+			t.Errorf("label '%s' missing from '%s'", e, labels)
+		}
+	}
+}
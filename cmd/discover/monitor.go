@@ -0,0 +1,256 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/vishvananda/netlink"
+	"k8s.io/klog/v2"
+
+	"github.com/intel/network-operator/pkg/lldp"
+)
+
+const (
+	metricsNamespace = "intel_network"
+	metricsSubsystem = "scaleout_port"
+
+	carrierChangesFile = "carrier_changes"
+)
+
+var (
+	operUpDesc = portDesc("oper_up",
+		"Whether the interface operational state is up.")
+	carrierChangesDesc = portDesc("carrier_changes_total",
+		"Number of carrier changes of the interface.")
+	rxErrorsDesc = portDesc("rx_errors_total",
+		"Number of receive errors on the interface.")
+	txErrorsDesc = portDesc("tx_errors_total",
+		"Number of transmit errors on the interface.")
+	rxDroppedDesc = portDesc("rx_dropped_total",
+		"Number of received packets dropped on the interface.")
+	txDroppedDesc = portDesc("tx_dropped_total",
+		"Number of transmitted packets dropped on the interface.")
+	lldpAgeDesc = portDesc("lldp_last_seen_seconds",
+		"Seconds since the last LLDP packet was received on the interface.")
+	gatewayReachableDesc = portDesc("gateway_reachable",
+		"Whether the LLDP peer answered the last reachability check.")
+	configuredDesc = portDesc("configured",
+		"Whether the interface was configured for scale-out.")
+)
+
+func portDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, metricsSubsystem, name),
+		help, []string{"interface"}, nil)
+}
+
+// portMonitor keeps track of the scale-out ports after they have been
+// configured and exports their health as Prometheus metrics.
+type portMonitor struct {
+	mutex          sync.Mutex
+	networkConfigs map[string]*networkConfiguration
+	lldpLastSeen   map[string]time.Time
+	reachable      map[string]bool
+	now            func() time.Time
+}
+
+func newPortMonitor(networkConfigs map[string]*networkConfiguration) *portMonitor {
+	m := &portMonitor{
+		networkConfigs: networkConfigs,
+		lldpLastSeen:   map[string]time.Time{},
+		reachable:      map[string]bool{},
+		now:            time.Now,
+	}
+
+	for ifname, nwconfig := range networkConfigs {
+		if nwconfig.portDescription != "" {
+			m.lldpLastSeen[ifname] = m.now()
+		}
+	}
+
+	return m
+}
+
+func (m *portMonitor) lldpSeen(ifname string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.lldpLastSeen[ifname] = m.now()
+}
+
+func (m *portMonitor) setReachable(ifname string, reachable bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.reachable[ifname] = reachable
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func carrierChanges(ifname string) (float64, error) {
+	content, err := os.ReadFile(filepath.Join(getSysfsRoot(), "class/net", ifname, carrierChangesFile))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(string(content)), 64)
+}
+
+// Describe implements prometheus.Collector.
+func (m *portMonitor) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		operUpDesc, carrierChangesDesc, rxErrorsDesc, txErrorsDesc, rxDroppedDesc,
+		txDroppedDesc, lldpAgeDesc, gatewayReachableDesc, configuredDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *portMonitor) Collect(ch chan<- prometheus.Metric) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for ifname, nwconfig := range m.networkConfigs {
+		ch <- prometheus.MustNewConstMetric(configuredDesc, prometheus.GaugeValue,
+			boolToFloat(nwconfig.configured), ifname)
+
+		if lastSeen, exists := m.lldpLastSeen[ifname]; exists {
+			ch <- prometheus.MustNewConstMetric(lldpAgeDesc, prometheus.GaugeValue,
+				m.now().Sub(lastSeen).Seconds(), ifname)
+		}
+
+		if reachable, exists := m.reachable[ifname]; exists {
+			ch <- prometheus.MustNewConstMetric(gatewayReachableDesc, prometheus.GaugeValue,
+				boolToFloat(reachable), ifname)
+		}
+
+		if changes, err := carrierChanges(ifname); err == nil {
+			ch <- prometheus.MustNewConstMetric(carrierChangesDesc, prometheus.CounterValue, changes, ifname)
+		}
+
+		link, err := networkLink.LinkByName(ifname)
+		if err != nil {
+			klog.V(4).Infof("Could not get link '%s' for metrics: %v", ifname, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(operUpDesc, prometheus.GaugeValue,
+			boolToFloat(link.Attrs().OperState == netlink.OperUp), ifname)
+
+		if stats := link.Attrs().Statistics; stats != nil {
+			ch <- prometheus.MustNewConstMetric(rxErrorsDesc, prometheus.CounterValue, float64(stats.RxErrors), ifname)
+			ch <- prometheus.MustNewConstMetric(txErrorsDesc, prometheus.CounterValue, float64(stats.TxErrors), ifname)
+			ch <- prometheus.MustNewConstMetric(rxDroppedDesc, prometheus.CounterValue, float64(stats.RxDropped), ifname)
+			ch <- prometheus.MustNewConstMetric(txDroppedDesc, prometheus.CounterValue, float64(stats.TxDropped), ifname)
+		}
+	}
+}
+
+// listenLLDP keeps receiving LLDP packets on the interface until the context
+// is done, recording when the peer was last seen.
+func (m *portMonitor) listenLLDP(ctx context.Context, nwconfig *networkConfiguration) {
+	ifname := nwconfig.link.Attrs().Name
+	results := make(chan lldp.DiscoveryResult, 1)
+
+	for ctx.Err() == nil {
+		client := lldp.NewClient(ctx, ifname, *nwconfig.localHwAddr)
+		if err := client.Start(results); err != nil {
+			klog.V(3).Infof("LLDP monitoring on '%s' failed: %v", ifname, err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Minute):
+			}
+
+			continue
+		}
+
+		select {
+		case <-results:
+			m.lldpSeen(ifname)
+		default:
+		}
+	}
+}
+
+// checkReachability pings the LLDP peer of every configured interface.
+func (m *portMonitor) checkReachability(config *cmdConfig) {
+	for ifname, nwconfig := range m.networkConfigs {
+		if !nwconfig.configured || nwconfig.localAddr == nil || nwconfig.lldpPeer == nil {
+			continue
+		}
+
+		err := pingFn(ifname, *nwconfig.localAddr, *nwconfig.lldpPeer, 0, config.verifyTimeout)
+		if err != nil {
+			klog.V(3).Infof("Interface '%s' gateway unreachable: %v", ifname, err)
+		}
+
+		m.setReachable(ifname, err == nil)
+	}
+}
+
+// run monitors the ports until the context is done.
+func (m *portMonitor) run(ctx context.Context, config *cmdConfig) {
+	if config.mode != L3 {
+		return
+	}
+
+	for _, nwconfig := range m.networkConfigs {
+		go m.listenLLDP(ctx, nwconfig)
+	}
+
+	ticker := time.NewTicker(config.monitorInterval)
+	defer ticker.Stop()
+
+	for {
+		m.checkReachability(config)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startMetricsServer serves the port metrics on the given address until the
+// context is done.
+func startMetricsServer(ctx context.Context, addr string, monitor *portMonitor) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(monitor)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	serveHTTP(ctx, "metrics", addr, mux)
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vishvananda/netlink"
+)
+
+func gatherPortMetrics(t *testing.T, monitor *portMonitor) map[string]map[string]float64 {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(monitor)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("could not gather metrics: %v", err)
+	}
+
+	values := map[string]map[string]float64{}
+
+	for _, family := range families {
+		values[family.GetName()] = map[string]float64{}
+
+		for _, metric := range family.GetMetric() {
+			ifname := metric.GetLabel()[0].GetValue()
+
+			if metric.GetGauge() != nil {
+				values[family.GetName()][ifname] = metric.GetGauge().GetValue()
+			} else {
+				values[family.GetName()][ifname] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return values
+}
+
+func TestPortMonitorMetrics(t *testing.T) {
+	testSysfsRoot, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testSysfsRoot)
+
+	os.Setenv("SYSFS_ROOT", testSysfsRoot)
+
+	carrierDir := filepath.Join(testSysfsRoot, "class/net/eth_a")
+	if err := os.MkdirAll(carrierDir, 0755); err != nil {
+		t.Errorf("cannot create fake sysfs dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(carrierDir, carrierChangesFile), []byte("4\n"), 0644); err != nil {
+		t.Errorf("cannot write carrier changes: %v", err)
+	}
+
+	networkLink.LinkByName = func(name string) (netlink.Link, error) {
+		if name == "eth_c" {
+			return nil, fmt.Errorf("no link")
+		}
+
+		return &fakeLink{
+			fakeAttrs: netlink.LinkAttrs{
+				Name:       name,
+				OperState:  netlink.OperUp,
+				Statistics: &netlink.LinkStatistics{RxErrors: 1, TxErrors: 2, RxDropped: 3, TxDropped: 4},
+			},
+		}, nil
+	}
+	defer func() { networkLink.LinkByName = fakeLinkByName }()
+
+	pingFn = func(ifname string, src, dst net.IP, seq int, timeout time.Duration) error {
+		if ifname == "eth_c" {
+			return fmt.Errorf("timeout")
+		}
+		return nil
+	}
+	defer func() { pingFn = icmpPing }()
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	lldpResults(nwconfigs)
+	nwconfigs["eth_a"].configured = true
+	nwconfigs["eth_c"].configured = true
+
+	start := time.Now()
+	monitor := newPortMonitor(nwconfigs)
+	monitor.now = func() time.Time { return start.Add(10 * time.Second) }
+
+	monitor.checkReachability(&cmdConfig{verifyTimeout: time.Second})
+
+	values := gatherPortMetrics(t, monitor)
+
+	prefix := metricsNamespace + "_" + metricsSubsystem + "_"
+
+	if values[prefix+"configured"]["eth_a"] != 1 || values[prefix+"configured"]["eth_b"] != 0 {
+		t.Errorf("wrong configured metrics: %v", values[prefix+"configured"])
+	}
+	if values[prefix+"gateway_reachable"]["eth_a"] != 1 || values[prefix+"gateway_reachable"]["eth_c"] != 0 {
+		t.Errorf("wrong reachability metrics: %v", values[prefix+"gateway_reachable"])
+	}
+	if _, exists := values[prefix+"gateway_reachable"]["eth_b"]; exists {
+		t.Errorf("unconfigured eth_b should have no reachability metric")
+	}
+	if age := values[prefix+"lldp_last_seen_seconds"]["eth_a"]; age < 9 || age > 11 {
+		t.Errorf("wrong LLDP age %f", age)
+	}
+	if values[prefix+"carrier_changes_total"]["eth_a"] != 4 {
+		t.Errorf("wrong carrier changes: %v", values[prefix+"carrier_changes_total"])
+	}
+	if values[prefix+"oper_up"]["eth_b"] != 1 || values[prefix+"tx_dropped_total"]["eth_b"] != 4 {
+		t.Errorf("wrong link metrics for eth_b: %v", values)
+	}
+	if _, exists := values[prefix+"oper_up"]["eth_c"]; exists {
+		t.Errorf("missing eth_c should have no link metrics")
+	}
+
+	monitor.lldpSeen("eth_a")
+	if age := gatherPortMetrics(t, monitor)[prefix+"lldp_last_seen_seconds"]["eth_a"]; age != 0 {
+		t.Errorf("LLDP age should have been reset, got %f", age)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"k8s.io/klog/v2"
+)
+
+const (
+	NetplanPath = "/etc/netplan"
+
+	// sorted after the distribution files so that it overrides them
+	netplanFile = "90-intel-network-operator.yaml"
+)
+
+// netplanGenerate has netplan generate the backend configuration from the
+// files, which also validates them. The container has no netplan, so it is
+// run through the netplan D-Bus service of the host. Tests replace it.
+var netplanGenerate = func() error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+
+	return conn.Object("io.netplan.Netplan", "/io/netplan/Netplan").
+		Call("io.netplan.Netplan.Generate", 0).Err
+}
+
+func netplanFilename(netplanpath string) string {
+	return filepath.Join(netplanpath, netplanFile)
+}
+
+func netplanEthernet(ifname string, nwconfig *networkConfiguration, mtu int) string {
+	networkMask := net.CIDRMask(int(RouteMaskRoutedNetwork), 32)
+	networkAddr := nwconfig.localAddr.Mask(networkMask)
+
+	ethernet := fmt.Sprintf("    %s:\n"+
+		"      match:\n"+
+		"        macaddress: \"%s\"\n"+
+		"      dhcp4: false\n"+
+		"      dhcp6: false\n"+
+		"      link-local: []\n",
+		ifname, nwconfig.link.Attrs().HardwareAddr.String())
+
+	if mtu > 0 {
+		ethernet += fmt.Sprintf("      mtu: %d\n", mtu)
+	}
+
+	ethernet += fmt.Sprintf("      addresses:\n"+
+		"        - %s/%d\n"+
+		"      routes:\n"+
+		"        - to: %s/%d\n"+
+		"          via: %s\n",
+		nwconfig.localAddr.String(), int(RouteMaskPointToPoint),
+		networkAddr, int(RouteMaskRoutedNetwork),
+		nwconfig.lldpPeer.String())
+
+	return ethernet
+}
+
+// WriteNetplan writes a netplan file owned by the operator with the address,
+// MTU and routes of every interface configured with an address from LLDP.
+// The file is removed on cleanup as one of the files of the host state.
+// Returns the configured interfaces.
+func WriteNetplan(netplanpath string, networkConfigs map[string]*networkConfiguration, mtu int) ([]string, error) {
+	configured := []string{}
+
+	for ifname, nwconfig := range networkConfigs {
+		if !nwconfig.configured || nwconfig.localAddr == nil || nwconfig.lldpPeer == nil {
+			continue
+		}
+
+		if err := checkNetworkConfig(ifname, nwconfig); err != nil {
+			return nil, err
+		}
+
+		configured = append(configured, ifname)
+	}
+
+	slices.Sort(configured)
+
+	var content strings.Builder
+
+	content.WriteString("# Netplan configuration created by network-operator\n" +
+		"network:\n" +
+		"  version: 2\n")
+
+	if len(configured) > 0 {
+		content.WriteString("  ethernets:\n")
+	}
+
+	for _, ifname := range configured {
+		content.WriteString(netplanEthernet(ifname, networkConfigs[ifname], mtu))
+	}
+
+	filename := netplanFilename(netplanpath)
+
+	// netplan warns about files readable by others
+	if err := os.WriteFile(filename, []byte(content.String()), 0600); err != nil {
+		return nil, fmt.Errorf("could not write netplan file '%s': %v", filename, err)
+	}
+
+	return configured, nil
+}
+
+// persistNetplan writes the netplan file and has netplan generate the
+// configuration from it, so that errors in the file show up now rather than
+// at the next boot.
+func persistNetplan(config *cmdConfig, networkConfigs map[string]*networkConfiguration, state *hostState) error {
+	state.addFiles(netplanFilename(config.netplan))
+
+	if _, err := WriteNetplan(config.netplan, networkConfigs, config.mtu); err != nil {
+		return fmt.Errorf("Could not create netplan configuration: %v", err)
+	}
+
+	if err := netplanGenerate(); err != nil {
+		return fmt.Errorf("netplan generate failed: %v", err)
+	}
+
+	klog.Infof("Generated netplan configuration")
+
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestNetplan(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	// reuse earlier test data and create local addresses
+	all := getFakeNetworkDataConfigs()
+	nwconfigs := map[string]*networkConfiguration{"eth_a": all["eth_a"], "eth_b": all["eth_b"]}
+	_ = lldpResults(nwconfigs)
+
+	for _, nwconfig := range nwconfigs {
+		nwconfig.configured = nwconfig.localAddr != nil
+	}
+
+	configured, err := WriteNetplan(testDir, nwconfigs, 8000)
+	if err != nil {
+		t.Fatalf("could not write netplan file: %v", err)
+	}
+
+	if len(configured) != 1 || configured[0] != "eth_a" {
+		t.Errorf("expected only eth_a to be configured, got %v", configured)
+	}
+
+	content, err := os.ReadFile(netplanFilename(testDir))
+	if err != nil {
+		t.Fatalf("could not read netplan file: %v", err)
+	}
+
+	expected := "# Netplan configuration created by network-operator\n" +
+		"network:\n" +
+		"  version: 2\n" +
+		"  ethernets:\n" +
+		"    eth_a:\n" +
+		"      match:\n" +
+		"        macaddress: \"0a:0b:0c:0d:0e:0f\"\n" +
+		"      dhcp4: false\n" +
+		"      dhcp6: false\n" +
+		"      link-local: []\n" +
+		"      mtu: 8000\n" +
+		"      addresses:\n" +
+		"        - 10.210.8.121/30\n" +
+		"      routes:\n" +
+		"        - to: 10.210.0.0/16\n" +
+		"          via: 10.210.8.122\n"
+
+	if string(content) != expected {
+		t.Errorf("unexpected netplan content:\n%s\nexpected:\n%s", content, expected)
+	}
+
+	// an interface whose configuration failed is not persisted
+	nwconfigs["eth_a"].configured = false
+
+	configured, err = WriteNetplan(testDir, nwconfigs, 8000)
+	if err != nil {
+		t.Fatalf("could not write netplan file: %v", err)
+	}
+	if len(configured) != 0 {
+		t.Errorf("expected no interfaces to be configured, got %v", configured)
+	}
+}
+
+func TestPersistNetplanGenerate(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	generated := 0
+	origGenerate := netplanGenerate
+	netplanGenerate = func() error {
+		generated++
+		return nil
+	}
+	defer func() { netplanGenerate = origGenerate }()
+
+	config := &cmdConfig{netplan: testDir, mtu: 8000}
+	nwconfigs := getFakeNetworkDataConfigs()
+
+	state := &hostState{}
+	if err := persistNetplan(config, nwconfigs, state); err != nil {
+		t.Fatalf("could not persist netplan configuration: %v", err)
+	}
+
+	if generated != 1 {
+		t.Errorf("expected netplan generate to run once, got %d", generated)
+	}
+
+	if len(state.Files) != 1 {
+		t.Errorf("expected the netplan file in the host state, got %v", state.Files)
+	}
+
+	netplanGenerate = func() error {
+		return errors.New("invalid YAML")
+	}
+
+	if err := persistNetplan(config, nwconfigs, state); err == nil {
+		t.Errorf("netplan generate errors should be returned")
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -45,6 +46,8 @@ type networkLinkFn struct {
 	AddrDel       func(link netlink.Link, addr *netlink.Addr) error
 	LinkSubscribe func(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error
 	RouteAppend   func(route *netlink.Route) error
+	RouteList     func(link netlink.Link, family int) ([]netlink.Route, error)
+	RouteDel      func(route *netlink.Route) error
 	LinkSetUp     func(link netlink.Link) error
 	LinkSetDown   func(link netlink.Link) error
 	LinkSetMTU    func(link netlink.Link, mtu int) error
@@ -57,6 +60,8 @@ var networkLink = networkLinkFn{
 	AddrDel:       netlink.AddrDel,
 	LinkSubscribe: netlink.LinkSubscribe,
 	RouteAppend:   netlink.RouteAppend,
+	RouteList:     netlink.RouteList,
+	RouteDel:      netlink.RouteDel,
 	LinkSetUp:     netlink.LinkSetUp,
 	LinkSetDown:   netlink.LinkSetDown,
 	LinkSetMTU:    netlink.LinkSetMTU,
@@ -71,6 +76,13 @@ type networkConfiguration struct {
 	localAddr       *net.IP
 	peerHWAddr      *net.HardwareAddr
 	localHwAddr     *net.HardwareAddr
+	configured      bool
+	unreachable     bool
+}
+
+// ready tells whether the interface counts towards the node readiness.
+func (n *networkConfiguration) ready() bool {
+	return n.configured && !n.unreachable
 }
 
 func getSysfsRoot() string {
@@ -378,23 +390,60 @@ func addRoute(nwconfig *networkConfiguration, mask RouteMask) error {
 	return err
 }
 
-func interfacesSetMTU(networkConfigurations map[string]*networkConfiguration, mtu int) {
+func interfacesSetMTU(networkConfigurations map[string]*networkConfiguration, mtu int) error {
+	errs := []error{}
+
 	for _, nwconfig := range networkConfigurations {
 		if err := networkLink.LinkSetMTU(nwconfig.link, mtu); err != nil {
 			klog.Warningf("Could not set MTU %d for interface '%s': %v",
 				mtu, nwconfig.link.Attrs().Name, err)
+
+			errs = append(errs, fmt.Errorf("could not set MTU %d for interface '%s': %v",
+				mtu, nwconfig.link.Attrs().Name, err))
 		}
 	}
+
+	return errors.Join(errs...)
+}
+
+// checkPlannedAddresses verifies that LLDP provided an address for every
+// interface before any of them is configured.
+func checkPlannedAddresses(networkConfigs map[string]*networkConfiguration) error {
+	missing := []string{}
+
+	for ifname, nwconfig := range networkConfigs {
+		if nwconfig.localAddr == nil || nwconfig.lldpPeer == nil {
+			missing = append(missing, ifname)
+		}
+	}
+
+	if len(missing) > 0 {
+		slices.Sort(missing)
+
+		return fmt.Errorf("No LLDP address for interfaces %s", strings.Join(missing, ", "))
+	}
+
+	return nil
 }
 
 func removeExistingIPs(networkConfigs map[string]*networkConfiguration) error {
-	for _, nwconfig := range networkConfigs {
+	return removeIPsExcept(networkConfigs, nil)
+}
+
+// removeIPsExcept removes IPv4 addresses from the interfaces, leaving out
+// the addresses listed in keep for the interface.
+func removeIPsExcept(networkConfigs map[string]*networkConfiguration, keep map[string][]string) error {
+	for ifname, nwconfig := range networkConfigs {
 		addrs, err := networkLink.AddrList(nwconfig.link, netlink.FAMILY_V4)
 		if err != nil {
 			return err
 		}
 
 		for _, addr := range addrs {
+			if slices.Contains(keep[ifname], addr.IPNet.String()) {
+				continue
+			}
+
 			if err := networkLink.AddrDel(nwconfig.link, &addr); err != nil {
 				return err
 			}
@@ -404,6 +453,22 @@ func removeExistingIPs(networkConfigs map[string]*networkConfiguration) error {
 	return nil
 }
 
+// configuredAddresses returns the /30 addresses selected for the interfaces.
+func configuredAddresses(networkConfigs map[string]*networkConfiguration) map[string][]string {
+	addrs := map[string][]string{}
+
+	for ifname, nwconfig := range networkConfigs {
+		if nwconfig.localAddr == nil {
+			continue
+		}
+
+		ipnet := net.IPNet{IP: *nwconfig.localAddr, Mask: net.CIDRMask(30, 32)}
+		addrs[ifname] = []string{ipnet.String()}
+	}
+
+	return addrs
+}
+
 func configureInterfaces(networkConfigs map[string]*networkConfiguration) (int, int) {
 	configured := 0
 
@@ -462,6 +527,7 @@ func configureInterfaces(networkConfigs map[string]*networkConfiguration) (int,
 			continue
 		}
 
+		nwconfig.configured = true
 		configured++
 	}
 
@@ -22,6 +22,7 @@ import (
 	"net"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/vishvananda/netlink"
@@ -589,14 +590,41 @@ func TestInterfaceDownErrors(t *testing.T) {
 	}
 }
 
-func TestSetLinkMTUWarning(_ *testing.T) {
+func TestSetLinkMTUWarning(t *testing.T) {
 	netConfs := getFakeNetworkDataConfigs()
 
 	networkLink.LinkSetMTU = func(link netlink.Link, mtu int) error {
 		return fmt.Errorf("cant set mtu")
 	}
 
-	interfacesSetMTU(netConfs, 8080)
+	if err := interfacesSetMTU(netConfs, 8080); err == nil {
+		t.Error("interfacesSetMTU should have failed")
+	}
+
+	networkLink.LinkSetMTU = func(link netlink.Link, mtu int) error {
+		return nil
+	}
+
+	if err := interfacesSetMTU(netConfs, 8080); err != nil {
+		t.Errorf("interfacesSetMTU should have passed: %v", err)
+	}
+}
+
+func TestCheckPlannedAddresses(t *testing.T) {
+	netConfs := getFakeNetworkDataConfigs()
+
+	lldpResults(netConfs)
+
+	err := checkPlannedAddresses(netConfs)
+	if err == nil || !strings.Contains(err.Error(), "eth_b") {
+		t.Errorf("checkPlannedAddresses should have failed for eth_b: %v", err)
+	}
+
+	delete(netConfs, "eth_b")
+
+	if err := checkPlannedAddresses(netConfs); err != nil {
+		t.Errorf("checkPlannedAddresses should have passed: %v", err)
+	}
 }
 
 func TestRemoveExistingIPs(t *testing.T) {
@@ -613,6 +641,28 @@ func TestRemoveExistingIPs(t *testing.T) {
 	}
 }
 
+func TestRemoveIPsExcept(t *testing.T) {
+	netConfs := getFakeNetworkDataConfigs()
+	removed := []string{}
+
+	networkLink.AddrDel = func(link netlink.Link, addr *netlink.Addr) error {
+		removed = append(removed, addr.IPNet.String())
+		return nil
+	}
+	networkLink.AddrList = fakeLinkAddrList
+
+	localaddr := net.IPv4(10, 210, 8, 125)
+	netConfs["eth_c"].localAddr = &localaddr
+
+	if err := removeIPsExcept(netConfs, configuredAddresses(netConfs)); err != nil {
+		t.Errorf("removeIPsExcept should have passed: %v", err)
+	}
+
+	if len(removed) != 1 || removed[0] != "192.192.192.1/24" {
+		t.Errorf("expected only the unconfigured address to be removed, got %v", removed)
+	}
+}
+
 func TestRemoveExistingIPsErrors(t *testing.T) {
 	netConfs := getFakeNetworkDataConfigs()
 
@@ -0,0 +1,70 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	nm "github.com/intel/network-operator/internal/nm"
+)
+
+// connectionProfiles returns NetworkManager connection profiles with the
+// same address and routes that configureInterfaces applied.
+func connectionProfiles(networkConfigs map[string]*networkConfiguration, mtu int) []nm.ConnectionProfile {
+	profiles := []nm.ConnectionProfile{}
+
+	for ifname, nwconfig := range networkConfigs {
+		if !nwconfig.configured || nwconfig.localAddr == nil || nwconfig.lldpPeer == nil {
+			continue
+		}
+
+		routedMask := net.CIDRMask(int(RouteMaskRoutedNetwork), 32)
+
+		profiles = append(profiles, nm.ConnectionProfile{
+			Interface:    ifname,
+			HardwareAddr: nwconfig.link.Attrs().HardwareAddr,
+			MTU:          mtu,
+			Address: &net.IPNet{
+				IP:   *nwconfig.localAddr,
+				Mask: net.CIDRMask(int(RouteMaskPointToPoint), 32),
+			},
+			Routes: []nm.Route{{
+				Destination: &net.IPNet{IP: nwconfig.localAddr.Mask(routedMask), Mask: routedMask},
+				NextHop:     *nwconfig.lldpPeer,
+			}},
+		})
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Interface < profiles[j].Interface
+	})
+
+	return profiles
+}
+
+// writeConnectionProfiles makes NetworkManager own the configured interfaces
+// with persistent connection profiles. Returns the interfaces with a profile.
+func writeConnectionProfiles(networkConfigs map[string]*networkConfiguration, mtu int) ([]string, error) {
+	nmapi, err := nm.NewNetworkManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NetworkManager: %v", err)
+	}
+
+	return nm.ApplyConnectionProfiles(nmapi, connectionProfiles(networkConfigs, mtu))
+}
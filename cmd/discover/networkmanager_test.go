@@ -0,0 +1,54 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestConnectionProfiles(t *testing.T) {
+	nwconfigs := getFakeNetworkDataConfigs()
+
+	local := net.IPv4(10, 210, 8, 121)
+	peer := net.IPv4(10, 210, 8, 122)
+
+	nwconfigs["eth_a"].configured = true
+	nwconfigs["eth_a"].localAddr = &local
+	nwconfigs["eth_a"].lldpPeer = &peer
+
+	// not configured, no profile
+	nwconfigs["eth_b"].localAddr = &local
+	nwconfigs["eth_b"].lldpPeer = &peer
+
+	profiles := connectionProfiles(nwconfigs, 8000)
+	if len(profiles) != 1 {
+		t.Fatalf("expected one profile, got %v", profiles)
+	}
+
+	profile := profiles[0]
+	if profile.Interface != "eth_a" || profile.MTU != 8000 {
+		t.Errorf("wrong profile: %+v", profile)
+	}
+	if profile.Address.String() != "10.210.8.121/30" {
+		t.Errorf("wrong profile address: %s", profile.Address)
+	}
+	if len(profile.Routes) != 1 || profile.Routes[0].Destination.String() != "10.210.0.0/16" ||
+		!profile.Routes[0].NextHop.Equal(peer) {
+		t.Errorf("wrong profile routes: %+v", profile.Routes)
+	}
+}
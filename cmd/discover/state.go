@@ -0,0 +1,436 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	"k8s.io/klog/v2"
+
+	nm "github.com/intel/network-operator/internal/nm"
+)
+
+const (
+	stateDir         = "/var/lib/intel-network-operator/"
+	defaultStateFile = stateDir + "discover-state.json"
+
+	// stateVersion is increased whenever the journal format changes in
+	// an incompatible way
+	stateVersion = 1
+)
+
+// linkState records the state of an interface before discover modified it.
+type linkState struct {
+	Up        bool     `json:"up"`
+	MTU       int      `json:"mtu"`
+	Addresses []string `json:"addresses,omitempty"`
+	// gateway routes present before discover, never removed by it
+	Routes    []routeState `json:"routes,omitempty"`
+	NMManaged *bool        `json:"nmManaged,omitempty"`
+}
+
+// routeState is a gateway route of an interface.
+type routeState struct {
+	Dst string `json:"dst"`
+	Gw  string `json:"gw"`
+}
+
+// appliedState records the configuration discover applied to an interface.
+type appliedState struct {
+	MTU       int          `json:"mtu"`
+	Addresses []string     `json:"addresses,omitempty"`
+	Routes    []routeState `json:"routes,omitempty"`
+}
+
+type interfaceState struct {
+	Original linkState     `json:"original"`
+	Applied  *appliedState `json:"applied,omitempty"`
+}
+
+// hostState is the journal persisted on the host. The original state is
+// recorded only once, when an interface is seen for the first time, and
+// kept over restarts so that cleanup can always revert to it.
+type hostState struct {
+	Version    int                        `json:"version"`
+	Interfaces map[string]*interfaceState `json:"interfaces"`
+	Files      []string                   `json:"files,omitempty"`
+	// interfaces with a NetworkManager connection profile
+	Connections []string `json:"connections,omitempty"`
+}
+
+func newHostState(networkConfigs map[string]*networkConfiguration) *hostState {
+	state := &hostState{
+		Version:    stateVersion,
+		Interfaces: make(map[string]*interfaceState, len(networkConfigs)),
+	}
+
+	state.addInterfaces(networkConfigs)
+
+	return state
+}
+
+// addInterfaces records the original state for the interfaces not yet in
+// the journal. For the interfaces already in the journal, the original link
+// state is used in place of the current one.
+func (s *hostState) addInterfaces(networkConfigs map[string]*networkConfiguration) {
+	for ifname, nwconfig := range networkConfigs {
+		if ifstate, exists := s.Interfaces[ifname]; exists {
+			if ifstate.Original.Up {
+				nwconfig.origState |= net.FlagUp
+			} else {
+				nwconfig.origState &^= net.FlagUp
+			}
+
+			continue
+		}
+
+		ifstate := &interfaceState{
+			Original: linkState{
+				Up:  nwconfig.origState&net.FlagUp != 0,
+				MTU: nwconfig.link.Attrs().MTU,
+			},
+		}
+
+		addrs, err := networkLink.AddrList(nwconfig.link, netlink.FAMILY_V4)
+		if err != nil {
+			klog.Warningf("Could not get addresses for link '%s': %v", ifname, err)
+		}
+
+		for _, addr := range addrs {
+			ifstate.Original.Addresses = append(ifstate.Original.Addresses, addr.IPNet.String())
+		}
+
+		ifstate.Original.Routes = gatewayRoutes(nwconfig.link)
+
+		s.Interfaces[ifname] = ifstate
+	}
+}
+
+func (s *hostState) addFiles(filenames ...string) {
+	for _, filename := range filenames {
+		if !slices.Contains(s.Files, filename) {
+			s.Files = append(s.Files, filename)
+		}
+	}
+}
+
+func (s *hostState) addConnections(interfaces ...string) {
+	for _, ifname := range interfaces {
+		if !slices.Contains(s.Connections, ifname) {
+			s.Connections = append(s.Connections, ifname)
+		}
+	}
+}
+
+// setNMManaged records the NetworkManager managed state for interfaces that
+// do not have it recorded yet; on restarts NetworkManager already reports
+// the interfaces as unmanaged.
+func (s *hostState) setNMManaged(managed map[string]bool) {
+	for ifname, m := range managed {
+		if ifstate, exists := s.Interfaces[ifname]; exists && ifstate.Original.NMManaged == nil {
+			ifstate.Original.NMManaged = &m
+		}
+	}
+}
+
+// appliedAddresses returns the addresses discover configured on a previous
+// run, per interface.
+func (s *hostState) appliedAddresses() map[string][]string {
+	addrs := map[string][]string{}
+
+	for ifname, ifstate := range s.Interfaces {
+		if ifstate.Applied != nil {
+			addrs[ifname] = ifstate.Applied.Addresses
+		}
+	}
+
+	return addrs
+}
+
+// gatewayRoutes returns the routes of the interface that go via a gateway.
+func gatewayRoutes(link netlink.Link) []routeState {
+	routes, err := networkLink.RouteList(link, netlink.FAMILY_V4)
+	if err != nil {
+		klog.Warningf("Could not get routes for link '%s': %v", link.Attrs().Name, err)
+	}
+
+	gwroutes := []routeState{}
+
+	for _, route := range routes {
+		if route.Gw != nil && route.Dst != nil {
+			gwroutes = append(gwroutes, routeState{Dst: route.Dst.String(), Gw: route.Gw.String()})
+		}
+	}
+
+	return gwroutes
+}
+
+// setApplied records the addresses and gateway routes added on the
+// interfaces after configuring them. The ones present originally are left
+// out, so that cleanup never removes them.
+func (s *hostState) setApplied(networkConfigs map[string]*networkConfiguration, mtu int) {
+	for ifname, nwconfig := range networkConfigs {
+		ifstate, exists := s.Interfaces[ifname]
+		if !exists {
+			continue
+		}
+
+		applied := &appliedState{MTU: mtu}
+
+		addrs, err := networkLink.AddrList(nwconfig.link, netlink.FAMILY_V4)
+		if err != nil {
+			klog.Warningf("Could not get addresses for link '%s': %v", ifname, err)
+		}
+
+		for _, addr := range addrs {
+			if !slices.Contains(ifstate.Original.Addresses, addr.IPNet.String()) {
+				applied.Addresses = append(applied.Addresses, addr.IPNet.String())
+			}
+		}
+
+		for _, route := range gatewayRoutes(nwconfig.link) {
+			if !slices.Contains(ifstate.Original.Routes, route) {
+				applied.Routes = append(applied.Routes, route)
+			}
+		}
+
+		ifstate.Applied = applied
+	}
+}
+
+func (s *hostState) clearApplied() {
+	for _, ifstate := range s.Interfaces {
+		ifstate.Applied = nil
+	}
+}
+
+func loadHostState(filename string) (*hostState, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &hostState{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("could not parse host state '%s': %v", filename, err)
+	}
+
+	if state.Version != stateVersion {
+		return nil, fmt.Errorf("unsupported host state version %d in '%s'", state.Version, filename)
+	}
+
+	if state.Interfaces == nil {
+		state.Interfaces = map[string]*interfaceState{}
+	}
+
+	return state, nil
+}
+
+func saveHostState(filename string, state *hostState) error {
+	if filename == "" {
+		return nil
+	}
+
+	content, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("could not marshal host state: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return fmt.Errorf("could not create host state directory: %v", err)
+	}
+
+	// write and rename so that a crash never leaves a truncated file behind
+	tmpfile := filename + ".tmp"
+	if err := os.WriteFile(tmpfile, content, 0644); err != nil {
+		return fmt.Errorf("could not write host state '%s': %v", tmpfile, err)
+	}
+
+	return os.Rename(tmpfile, filename)
+}
+
+func restoreInterface(link netlink.Link, ifstate *interfaceState) error {
+	ifname := link.Attrs().Name
+	orig := ifstate.Original
+
+	// Only the gateway routes recorded as applied are removed, the /30 point
+	// to point routes disappear together with the addresses
+	if ifstate.Applied != nil && len(ifstate.Applied.Routes) > 0 {
+		routes, err := networkLink.RouteList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return fmt.Errorf("could not list routes for interface '%s': %v", ifname, err)
+		}
+
+		for _, route := range routes {
+			if route.Gw == nil || route.Dst == nil ||
+				!slices.Contains(ifstate.Applied.Routes, routeState{Dst: route.Dst.String(), Gw: route.Gw.String()}) {
+				continue
+			}
+
+			if err := networkLink.RouteDel(&route); err != nil {
+				return fmt.Errorf("could not remove route %s for interface '%s': %v", route.Dst, ifname, err)
+			}
+
+			klog.Infof("Removed route %s via %s from interface '%s'", route.Dst, route.Gw, ifname)
+		}
+	}
+
+	addrs, err := networkLink.AddrList(link, netlink.FAMILY_V4)
+	if err != nil {
+		return fmt.Errorf("could not get addresses for interface '%s': %v", ifname, err)
+	}
+
+	existing := []string{}
+
+	for _, addr := range addrs {
+		if slices.Contains(orig.Addresses, addr.IPNet.String()) {
+			existing = append(existing, addr.IPNet.String())
+			continue
+		}
+
+		if err := networkLink.AddrDel(link, &addr); err != nil {
+			return fmt.Errorf("could not remove address %s from interface '%s': %v", addr.IPNet, ifname, err)
+		}
+
+		klog.Infof("Removed address %s from interface '%s'", addr.IPNet, ifname)
+	}
+
+	for _, origaddr := range orig.Addresses {
+		if slices.Contains(existing, origaddr) {
+			continue
+		}
+
+		addr, err := netlink.ParseAddr(origaddr)
+		if err != nil {
+			return fmt.Errorf("could not parse original address %s for interface '%s': %v", origaddr, ifname, err)
+		}
+
+		if err := networkLink.AddrAdd(link, addr); err != nil {
+			return fmt.Errorf("could not restore address %s for interface '%s': %v", origaddr, ifname, err)
+		}
+
+		klog.Infof("Restored address %s for interface '%s'", origaddr, ifname)
+	}
+
+	if orig.MTU > 0 && link.Attrs().MTU != orig.MTU {
+		if err := networkLink.LinkSetMTU(link, orig.MTU); err != nil {
+			return fmt.Errorf("could not restore MTU %d for interface '%s': %v", orig.MTU, ifname, err)
+		}
+
+		klog.Infof("Restored MTU %d for interface '%s'", orig.MTU, ifname)
+	}
+
+	if !orig.Up && link.Attrs().Flags&net.FlagUp != 0 {
+		if err := networkLink.LinkSetDown(link); err != nil {
+			return fmt.Errorf("could not set interface '%s' back down: %v", ifname, err)
+		}
+
+		klog.Infof("Setting link '%s' back down", ifname)
+	}
+
+	return nil
+}
+
+// restoreHostState reverts the host to the state recorded before discover
+// configured it. All the changes are attempted and the errors returned
+// together.
+func restoreHostState(state *hostState) error {
+	errs := []error{}
+	reloadNetworkd := false
+
+	for _, filename := range state.Files {
+		if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("could not remove file '%s': %v", filename, err))
+			continue
+		}
+
+		klog.Infof("Removed file '%s'", filename)
+
+		if strings.HasSuffix(filename, ".network") {
+			reloadNetworkd = true
+		}
+	}
+
+	if reloadNetworkd {
+		if err := networkdReload(); err != nil {
+			klog.Warningf("Failed to reload systemd-networkd: %v", err)
+		}
+	}
+
+	// the profiles go first so that NetworkManager does not reapply them
+	if len(state.Connections) > 0 {
+		nmapi, err := nm.NewNetworkManager()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create NetworkManager: %v", err))
+		} else if err := nm.RemoveConnectionProfiles(nmapi, state.Connections); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for ifname, ifstate := range state.Interfaces {
+		link, err := networkLink.LinkByName(ifname)
+		if err != nil {
+			klog.Warningf("Link '%s' not found, cannot restore it: %v", ifname, err)
+			continue
+		}
+
+		if err := restoreInterface(link, ifstate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := restoreNMManaged(state); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// restoreNMManaged gives the interfaces NetworkManager managed originally
+// back to it.
+func restoreNMManaged(state *hostState) error {
+	nmManaged := []string{}
+
+	for ifname, ifstate := range state.Interfaces {
+		if ifstate.Original.NMManaged != nil && *ifstate.Original.NMManaged {
+			nmManaged = append(nmManaged, ifname)
+		}
+	}
+
+	if len(nmManaged) == 0 {
+		return nil
+	}
+
+	nmapi, err := nm.NewNetworkManager()
+	if err != nil {
+		return fmt.Errorf("failed to create NetworkManager: %v", err)
+	}
+
+	if err := nm.EnableNetworkManagerForInterfaces(nmapi, nmManaged); err != nil {
+		return fmt.Errorf("failed to enable interfaces in NetworkManager: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestNewHostState(t *testing.T) {
+	networkLink.AddrList = fakeLinkAddrList
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	nwconfigs["eth_a"].origState = net.FlagUp
+	nwconfigs["eth_a"].link.Attrs().MTU = 1500
+
+	state := newHostState(nwconfigs)
+
+	if len(state.Interfaces) != len(nwconfigs) {
+		t.Errorf("expected %d interfaces, got %d", len(nwconfigs), len(state.Interfaces))
+	}
+
+	if state.Version != stateVersion {
+		t.Errorf("expected version %d, got %d", stateVersion, state.Version)
+	}
+
+	ifstate := state.Interfaces["eth_a"].Original
+	if !ifstate.Up || ifstate.MTU != 1500 {
+		t.Errorf("wrong state recorded for eth_a: %+v", ifstate)
+	}
+	if len(ifstate.Addresses) != 1 || ifstate.Addresses[0] != "192.192.192.1/24" {
+		t.Errorf("wrong addresses recorded for eth_a: %v", ifstate.Addresses)
+	}
+	if state.Interfaces["eth_b"].Original.Up {
+		t.Errorf("eth_b should have been recorded down")
+	}
+
+	state.setNMManaged(map[string]bool{"eth_a": true, "eth_b": false, "foo": true})
+	if *state.Interfaces["eth_a"].Original.NMManaged != true || *state.Interfaces["eth_b"].Original.NMManaged != false {
+		t.Errorf("wrong NetworkManager state recorded")
+	}
+	if state.Interfaces["eth_c"].Original.NMManaged != nil {
+		t.Errorf("eth_c should have no NetworkManager state")
+	}
+
+	// NetworkManager reports unmanaged on the next run, the original is kept
+	state.setNMManaged(map[string]bool{"eth_a": false})
+	if *state.Interfaces["eth_a"].Original.NMManaged != true {
+		t.Errorf("original NetworkManager state was overwritten")
+	}
+
+	state.addFiles("/foo", "/bar", "/foo")
+	if len(state.Files) != 2 {
+		t.Errorf("expected two files, got %v", state.Files)
+	}
+}
+
+func TestSaveLoadHostState(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	if err := saveHostState("", &hostState{}); err != nil {
+		t.Errorf("saving without a file name should be a no-op: %v", err)
+	}
+
+	managed := true
+	state := &hostState{
+		Version: stateVersion,
+		Interfaces: map[string]*interfaceState{
+			"eth_a": {
+				Original: linkState{Up: true, MTU: 1500, Addresses: []string{"10.0.0.1/24"}, NMManaged: &managed},
+				Applied:  &appliedState{MTU: 8000, Addresses: []string{"10.210.8.121/30"}},
+			},
+		},
+		Files: []string{"/etc/foo"},
+	}
+
+	statefile := filepath.Join(testDir, stateDir, "state.json")
+	if err := saveHostState(statefile, state); err != nil {
+		t.Errorf("could not save host state: %v", err)
+	}
+
+	loaded, err := loadHostState(statefile)
+	if err != nil {
+		t.Fatalf("could not load host state: %v", err)
+	}
+
+	ifstate := loaded.Interfaces["eth_a"]
+	if ifstate == nil || !ifstate.Original.Up || ifstate.Original.MTU != 1500 || *ifstate.Original.NMManaged != true ||
+		len(ifstate.Original.Addresses) != 1 || len(loaded.Files) != 1 {
+		t.Errorf("loaded state differs: %+v", loaded)
+	}
+	if ifstate.Applied == nil || ifstate.Applied.MTU != 8000 || len(ifstate.Applied.Addresses) != 1 {
+		t.Errorf("loaded applied state differs: %+v", ifstate.Applied)
+	}
+
+	if err := os.WriteFile(statefile, []byte(`{"version":999,"interfaces":{}}`), 0644); err != nil {
+		t.Errorf("could not write state file: %v", err)
+	}
+	if _, err := loadHostState(statefile); err == nil {
+		t.Errorf("loading an unknown version should have failed")
+	}
+
+	if err := os.WriteFile(statefile, []byte("{garbage"), 0644); err != nil {
+		t.Errorf("could not write state file: %v", err)
+	}
+	if _, err := loadHostState(statefile); err == nil {
+		t.Errorf("loading garbage should have failed")
+	}
+}
+
+func TestRestoreHostState(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	writtenFile := filepath.Join(testDir, "gaudinet.json")
+	_ = os.WriteFile(writtenFile, []byte("{}"), 0644)
+
+	routesDeleted := []*netlink.Route{}
+	addrsDeleted := []*netlink.Addr{}
+	addrsAdded := []*netlink.Addr{}
+	mtuSet := 0
+	setDown := false
+
+	networkLink.LinkByName = func(name string) (netlink.Link, error) {
+		if name != "eth_a" {
+			return nil, fmt.Errorf("no link %s", name)
+		}
+		return &fakeLink{
+			fakeAttrs: netlink.LinkAttrs{Name: name, MTU: 8000, Flags: net.FlagUp},
+		}, nil
+	}
+	networkLink.RouteList = func(link netlink.Link, family int) ([]netlink.Route, error) {
+		_, dst, _ := net.ParseCIDR("10.210.0.0/16")
+		_, p2p, _ := net.ParseCIDR("10.210.8.120/30")
+		_, other, _ := net.ParseCIDR("10.220.0.0/16")
+		return []netlink.Route{
+			{Dst: dst, Gw: net.IPv4(10, 210, 8, 122)},
+			{Dst: p2p},
+			{Dst: other, Gw: net.IPv4(10, 210, 8, 122)},
+		}, nil
+	}
+	networkLink.RouteDel = func(route *netlink.Route) error {
+		routesDeleted = append(routesDeleted, route)
+		return nil
+	}
+	networkLink.AddrList = func(link netlink.Link, family int) ([]netlink.Addr, error) {
+		addr, _ := netlink.ParseAddr("10.210.8.121/30")
+		return []netlink.Addr{*addr}, nil
+	}
+	networkLink.AddrDel = func(link netlink.Link, addr *netlink.Addr) error {
+		addrsDeleted = append(addrsDeleted, addr)
+		return nil
+	}
+	networkLink.AddrAdd = func(link netlink.Link, addr *netlink.Addr) error {
+		addrsAdded = append(addrsAdded, addr)
+		return nil
+	}
+	networkLink.LinkSetMTU = func(link netlink.Link, mtu int) error {
+		mtuSet = mtu
+		return nil
+	}
+	networkLink.LinkSetDown = func(link netlink.Link) error {
+		setDown = true
+		return nil
+	}
+
+	state := &hostState{
+		Interfaces: map[string]*interfaceState{
+			"eth_a": {
+				Original: linkState{Up: false, MTU: 1500, Addresses: []string{"192.168.0.1/24"}},
+				Applied: &appliedState{
+					MTU:       8000,
+					Addresses: []string{"10.210.8.121/30"},
+					Routes:    []routeState{{Dst: "10.210.0.0/16", Gw: "10.210.8.122"}},
+				},
+			},
+			"missing": {Original: linkState{Up: false, MTU: 1500}},
+		},
+		Files: []string{writtenFile, filepath.Join(testDir, "never-written")},
+	}
+
+	if err := restoreHostState(state); err != nil {
+		t.Errorf("restoring host state failed: %v", err)
+	}
+
+	if _, err := os.Stat(writtenFile); err == nil {
+		t.Errorf("file '%s' was not removed", writtenFile)
+	}
+	if len(routesDeleted) != 1 || routesDeleted[0].Gw == nil {
+		t.Errorf("expected only the applied gateway route to be removed, got %v", routesDeleted)
+	}
+	if len(addrsDeleted) != 1 || addrsDeleted[0].IPNet.String() != "10.210.8.121/30" {
+		t.Errorf("expected configured address to be removed, got %v", addrsDeleted)
+	}
+	if len(addrsAdded) != 1 || addrsAdded[0].IPNet.String() != "192.168.0.1/24" {
+		t.Errorf("expected original address to be restored, got %v", addrsAdded)
+	}
+	if mtuSet != 1500 {
+		t.Errorf("expected MTU 1500 to be restored, got %d", mtuSet)
+	}
+	if !setDown {
+		t.Errorf("expected link to be set back down")
+	}
+
+	networkLink.RouteDel = func(route *netlink.Route) error {
+		return fmt.Errorf("cant remove route")
+	}
+	networkLink.LinkSetMTU = func(link netlink.Link, mtu int) error {
+		return fmt.Errorf("cant set mtu")
+	}
+
+	if err := restoreHostState(state); err == nil {
+		t.Errorf("restoring host state should have failed")
+	}
+
+	networkLink.LinkByName = fakeLinkByName
+	networkLink.AddrList = fakeLinkAddrList
+}
+
+func TestHostStateJournal(t *testing.T) {
+	networkLink.AddrList = fakeLinkAddrList
+	networkLink.RouteList = func(link netlink.Link, family int) ([]netlink.Route, error) {
+		return nil, nil
+	}
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	nwconfigs["eth_a"].link.Attrs().MTU = 1500
+	state := newHostState(nwconfigs)
+
+	// second run: eth_a was configured up with a new MTU by the first run
+	nwconfigs = getFakeNetworkDataConfigs()
+	nwconfigs["eth_a"].origState = net.FlagUp
+	nwconfigs["eth_a"].link.Attrs().MTU = 8000
+	delete(state.Interfaces, "eth_c")
+
+	state.addInterfaces(nwconfigs)
+
+	if state.Interfaces["eth_a"].Original.MTU != 1500 {
+		t.Errorf("original MTU was overwritten: %d", state.Interfaces["eth_a"].Original.MTU)
+	}
+	if nwconfigs["eth_a"].origState&net.FlagUp != 0 {
+		t.Errorf("original link state should have been taken from the journal")
+	}
+	if _, exists := state.Interfaces["eth_c"]; !exists {
+		t.Errorf("new interface eth_c should have been added")
+	}
+
+	networkLink.RouteList = func(link netlink.Link, family int) ([]netlink.Route, error) {
+		_, dst, _ := net.ParseCIDR("10.210.0.0/16")
+		return []netlink.Route{{Dst: dst, Gw: net.IPv4(10, 210, 8, 122)}}, nil
+	}
+	networkLink.AddrList = func(link netlink.Link, family int) ([]netlink.Addr, error) {
+		addr, _ := netlink.ParseAddr("10.210.8.121/30")
+		orig, _ := netlink.ParseAddr("192.192.192.1/24")
+		return []netlink.Addr{*addr, *orig}, nil
+	}
+
+	state.setApplied(nwconfigs, 8000)
+
+	applied := state.Interfaces["eth_a"].Applied
+	if applied == nil || applied.MTU != 8000 {
+		t.Fatalf("wrong applied state: %+v", applied)
+	}
+	if len(applied.Addresses) != 1 || applied.Addresses[0] != "10.210.8.121/30" {
+		t.Errorf("expected only the configured address to be applied, got %v", applied.Addresses)
+	}
+	if len(applied.Routes) != 1 || applied.Routes[0] != (routeState{Dst: "10.210.0.0/16", Gw: "10.210.8.122"}) {
+		t.Errorf("wrong applied routes: %v", applied.Routes)
+	}
+	if addrs := state.appliedAddresses()["eth_a"]; len(addrs) != 1 {
+		t.Errorf("wrong applied addresses: %v", addrs)
+	}
+
+	state.clearApplied()
+	if state.Interfaces["eth_a"].Applied != nil || len(state.appliedAddresses()) != 0 {
+		t.Errorf("applied state was not cleared")
+	}
+
+	networkLink.AddrList = fakeLinkAddrList
+}
+
+func TestHostStateOriginalRoutes(t *testing.T) {
+	networkLink.AddrList = fakeLinkAddrList
+
+	_, adminDst, _ := net.ParseCIDR("10.0.0.0/8")
+	_, dst, _ := net.ParseCIDR("10.210.0.0/16")
+	adminRoute := netlink.Route{Dst: adminDst, Gw: net.IPv4(192, 192, 192, 254)}
+
+	// the admin has a gateway route on the interface before discover runs
+	networkLink.RouteList = func(link netlink.Link, family int) ([]netlink.Route, error) {
+		return []netlink.Route{adminRoute}, nil
+	}
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	state := newHostState(nwconfigs)
+
+	if routes := state.Interfaces["eth_a"].Original.Routes; len(routes) != 1 ||
+		routes[0] != (routeState{Dst: "10.0.0.0/8", Gw: "192.192.192.254"}) {
+		t.Errorf("wrong original routes: %v", routes)
+	}
+
+	networkLink.RouteList = func(link netlink.Link, family int) ([]netlink.Route, error) {
+		return []netlink.Route{adminRoute, {Dst: dst, Gw: net.IPv4(10, 210, 8, 122)}}, nil
+	}
+
+	state.setApplied(nwconfigs, 8000)
+
+	applied := state.Interfaces["eth_a"].Applied
+	if len(applied.Routes) != 1 || applied.Routes[0] != (routeState{Dst: "10.210.0.0/16", Gw: "10.210.8.122"}) {
+		t.Errorf("expected only the route of discover to be applied, got %v", applied.Routes)
+	}
+
+	removed := []string{}
+	networkLink.RouteDel = func(route *netlink.Route) error {
+		removed = append(removed, route.Dst.String())
+		return nil
+	}
+	networkLink.AddrDel = func(link netlink.Link, addr *netlink.Addr) error {
+		return nil
+	}
+
+	if err := restoreInterface(nwconfigs["eth_a"].link, state.Interfaces["eth_a"]); err != nil {
+		t.Errorf("unexpected restore error: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "10.210.0.0/16" {
+		t.Errorf("the route of the admin must not be removed, removed %v", removed)
+	}
+
+	networkLink.RouteList = func(link netlink.Link, family int) ([]netlink.Route, error) {
+		return nil, nil
+	}
+}
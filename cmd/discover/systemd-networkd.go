@@ -21,6 +21,12 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -46,51 +52,163 @@ func checkNetworkConfig(ifname string, nwconfig *networkConfiguration) error {
 	return nil
 }
 
-func writeNetwork(networkdpath string, ifname string, nwconfig *networkConfiguration) error {
-	networkMask := net.CIDRMask(int(RouteMaskRoutedNetwork), 32)
-	networkAddr := nwconfig.localAddr.Mask(networkMask)
+// networkdOwnerMarker tells the files written by discover apart from the
+// ones written by the admins
+const networkdOwnerMarker = "created by network-operator"
+
+// networkdReload tells systemd-networkd to reload its configuration files.
+// Tests replace it.
+var networkdReload = func() error {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+
+	return conn.Object("org.freedesktop.network1", "/org/freedesktop/network1").
+		Call("org.freedesktop.network1.Manager.Reload", 0).Err
+}
 
+func networkContent(ifname string, nwconfig *networkConfiguration, mode string, mtu int) string {
 	network := fmt.Sprintf("[Match]\n"+
 		"MACAddress=%s\n"+
-		"\n"+
-		"[Network]\n"+
-		"Description=Networkd configuration for %s created by network-operator\n"+
-		"Address=%s/%d\n"+
+		"\n",
+		nwconfig.link.Attrs().HardwareAddr.String())
+
+	if mtu > 0 {
+		network += fmt.Sprintf("[Link]\n"+
+			"MTUBytes=%d\n"+
+			"\n", mtu)
+	}
+
+	network += fmt.Sprintf("[Network]\n"+
+		"Description=Networkd configuration for %s %s\n", ifname, networkdOwnerMarker)
+
+	if mode == L2 {
+		return network + "LinkLocalAddressing=no\n"
+	}
+
+	networkMask := net.CIDRMask(int(RouteMaskRoutedNetwork), 32)
+	networkAddr := nwconfig.localAddr.Mask(networkMask)
+
+	network += fmt.Sprintf("Address=%s/%d\n"+
 		"\n"+
 		"[Route]\n"+
-		"Destination=%s/%d\n",
-		nwconfig.link.Attrs().HardwareAddr.String(),
-		ifname,
+		"Destination=%s/%d\n"+
+		"Gateway=%s\n",
 		nwconfig.localAddr.String(), int(RouteMaskPointToPoint),
 		networkAddr, int(RouteMaskRoutedNetwork),
+		nwconfig.lldpPeer.String(),
 	)
 
+	return network
+}
+
+// writeNetwork writes the networkd file of an interface. Returns true if the
+// file content changed.
+func writeNetwork(networkdpath string, ifname string, content string) (bool, error) {
 	filename := networkdFilename(networkdpath, ifname)
-	if err := os.WriteFile(filename, []byte(network), 0644); err != nil {
-		return fmt.Errorf("could not write networkd config file '%s': %v", filename, err)
+
+	if old, err := os.ReadFile(filename); err == nil && string(old) == content {
+		return false, nil
 	}
 
-	return nil
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("could not write networkd config file '%s': %v", filename, err)
+	}
+
+	return true, nil
 }
 
-func WriteSystemdNetworkd(networkdpath string, networkConfigs map[string]*networkConfiguration) ([]string, error) {
-	configured := []string{}
+// networkdInterfaces returns the interfaces that discover configured: in L3
+// mode the ones configured with an address from LLDP, in L2 mode the ones set
+// up.
+func networkdInterfaces(networkConfigs map[string]*networkConfiguration, mode string) ([]string, error) {
+	interfaces := []string{}
 
 	for ifname, nwconfig := range networkConfigs {
-		if err := checkNetworkConfig(ifname, nwconfig); err != nil {
-			return nil, err
+		if !nwconfig.configured {
+			continue
+		}
+
+		if mode == L2 {
+			if nwconfig.link == nil || nwconfig.link.Attrs().HardwareAddr.String() == "" {
+				return nil, fmt.Errorf("no local hw address for %s", ifname)
+			}
+		} else {
+			if nwconfig.localAddr == nil || nwconfig.lldpPeer == nil {
+				continue
+			}
+
+			if err := checkNetworkConfig(ifname, nwconfig); err != nil {
+				return nil, err
+			}
 		}
+
+		interfaces = append(interfaces, ifname)
 	}
 
-	for ifname, nwconfig := range networkConfigs {
-		if err := writeNetwork(networkdpath, ifname, nwconfig); err != nil {
+	sort.Strings(interfaces)
+
+	return interfaces, nil
+}
+
+// WriteSystemdNetworkd writes networkd files matching the configuration
+// discover applied. Returns the configured interfaces and whether any file
+// changed.
+func WriteSystemdNetworkd(networkdpath string, networkConfigs map[string]*networkConfiguration, mode string, mtu int) ([]string, bool, error) {
+	interfaces, err := networkdInterfaces(networkConfigs, mode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	configured := []string{}
+	changed := false
+
+	for _, ifname := range interfaces {
+		written, err := writeNetwork(networkdpath, ifname, networkContent(ifname, networkConfigs[ifname], mode, mtu))
+		if err != nil {
 			DeleteSystemdNetworkd(networkdpath, configured)
-			return nil, err
+			return nil, changed, err
 		}
+
+		changed = changed || written
 		configured = append(configured, ifname)
 	}
 
-	return configured, nil
+	return configured, changed, nil
+}
+
+// PruneSystemdNetworkd removes the networkd files written by discover for
+// interfaces other than the given ones. Returns true if any file was removed.
+func PruneSystemdNetworkd(networkdpath string, keep []string) (bool, error) {
+	paths, err := filepath.Glob(filepath.Join(networkdpath, "*.network"))
+	if err != nil {
+		return false, err
+	}
+
+	pruned := false
+
+	for _, path := range paths {
+		ifname := strings.TrimSuffix(filepath.Base(path), ".network")
+		if slices.Contains(keep, ifname) {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || !strings.Contains(string(content), networkdOwnerMarker) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			return pruned, fmt.Errorf("could not remove stale networkd config file '%s': %v", path, err)
+		}
+
+		klog.Infof("Removed stale networkd config file '%s'", path)
+
+		pruned = true
+	}
+
+	return pruned, nil
 }
 
 func DeleteSystemdNetworkd(networkdpath string, configuredInterfaces []string) {
@@ -17,9 +17,11 @@
 package main
 
 import (
+	"errors"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/vishvananda/netlink"
@@ -68,6 +70,8 @@ func fakesystemdnetworkdconfigs() (map[string]*networkConfiguration, map[string]
 	_ = lldpResults(nwconfigs)
 
 	for iface, nwconfig := range nwconfigs {
+		nwconfig.configured = nwconfig.localAddr != nil
+
 		if nwconfig.localAddr == nil {
 			expectedoutput[iface] = ""
 		} else {
@@ -76,6 +80,7 @@ func fakesystemdnetworkdconfigs() (map[string]*networkConfiguration, map[string]
 			expectedoutput[iface] = "[Match]\nMACAddress=" +
 				nwconfig.link.Attrs().HardwareAddr.String() +
 				"\n\n" +
+				"[Link]\nMTUBytes=8000\n\n" +
 				"[Network]\nDescription=Networkd configuration for " +
 				iface +
 				" created by network-operator\n" +
@@ -83,7 +88,8 @@ func fakesystemdnetworkdconfigs() (map[string]*networkConfiguration, map[string]
 				nwconfig.localAddr.String() + "/30" +
 				"\n\n" +
 				"[Route]\nDestination=" +
-				networkAddr.String() + "/16\n"
+				networkAddr.String() + "/16\n" +
+				"Gateway=" + nwconfig.lldpPeer.String() + "\n"
 		}
 	}
 
@@ -108,14 +114,22 @@ func TestSystemdNetworkdConfig(t *testing.T) {
 		configfile := filepath.Join(testDir, SystemdNetworkdPath, iface+".network")
 		expectedstr := expectedoutput[iface]
 
-		ifacelist, err := WriteSystemdNetworkd(confDir, map[string]*networkConfiguration{iface: nwconfig})
+		ifacelist, changed, err := WriteSystemdNetworkd(confDir, map[string]*networkConfiguration{iface: nwconfig}, L3, 8000)
 		if err != nil {
-			if expectedstr == "" {
-				continue
-			}
 			t.Errorf("could not create config file %s: %v", configfile, err)
 		}
 
+		if expectedstr == "" {
+			if len(ifacelist) != 0 || changed {
+				t.Errorf("interface '%s' without an LLDP address should have been skipped", iface)
+			}
+			continue
+		}
+
+		if !changed {
+			t.Errorf("config file %s should have been reported as changed", configfile)
+		}
+
 		if len(ifacelist) != 1 {
 			t.Errorf("received wrong number of configured interfaces (%d)", len(ifacelist))
 		}
@@ -129,6 +143,153 @@ func TestSystemdNetworkdConfig(t *testing.T) {
 			t.Errorf("read config file '%s', expected\n'%s', got \n'%s': %v",
 				configfile, expectedstr, string(configuredstr), err)
 		}
+
+		_, changed, err = WriteSystemdNetworkd(confDir, map[string]*networkConfiguration{iface: nwconfig}, L3, 8000)
+		if err != nil || changed {
+			t.Errorf("rewriting identical config file %s should not change it: %v", configfile, err)
+		}
+	}
+}
+
+func TestSystemdNetworkdConfigUnconfigured(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	nwconfigs, _ := fakesystemdnetworkdconfigs()
+
+	// eth_a has an LLDP address but failed to configure under best-effort
+	nwconfigs["eth_a"].configured = false
+
+	configured, _, err := WriteSystemdNetworkd(testDir, nwconfigs, L3, 8000)
+	if err != nil {
+		t.Fatalf("could not write L3 config files: %v", err)
+	}
+
+	if slices.Contains(configured, "eth_a") {
+		t.Errorf("unconfigured eth_a should have been skipped, got %v", configured)
+	}
+
+	if _, err := os.Stat(networkdFilename(testDir, "eth_a")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("no networkd file should have been written for eth_a: %v", err)
+	}
+}
+
+func TestSystemdNetworkdConfigL2(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	nwconfigs["eth_a"].configured = true
+	nwconfigs["eth_b"].configured = true
+
+	configured, _, err := WriteSystemdNetworkd(testDir, nwconfigs, L2, 0)
+	if err != nil {
+		t.Fatalf("could not write L2 config files: %v", err)
+	}
+
+	if len(configured) != 2 || configured[0] != "eth_a" || configured[1] != "eth_b" {
+		t.Errorf("expected eth_a and eth_b to be configured, got %v", configured)
+	}
+
+	expected := "[Match]\nMACAddress=0a:0b:0c:0d:0e:0f\n\n" +
+		"[Network]\nDescription=Networkd configuration for eth_a created by network-operator\n" +
+		"LinkLocalAddressing=no\n"
+
+	content, err := os.ReadFile(networkdFilename(testDir, "eth_a"))
+	if string(content) != expected {
+		t.Errorf("expected\n'%s', got\n'%s': %v", expected, string(content), err)
+	}
+}
+
+func TestPruneSystemdNetworkd(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	owned := "[Network]\nDescription=Networkd configuration for eth created by network-operator\n"
+	files := map[string]string{
+		"eth_a": owned,
+		"eth_b": owned,
+		"eth_c": "[Network]\nDescription=admin configuration\n",
+	}
+
+	for ifname, content := range files {
+		_ = os.WriteFile(networkdFilename(testDir, ifname), []byte(content), 0644)
+	}
+
+	pruned, err := PruneSystemdNetworkd(testDir, []string{"eth_a"})
+	if err != nil || !pruned {
+		t.Errorf("expected stale files to be pruned: %v", err)
+	}
+
+	for ifname, exists := range map[string]bool{"eth_a": true, "eth_b": false, "eth_c": true} {
+		_, err := os.Stat(networkdFilename(testDir, ifname))
+		if (err == nil) != exists {
+			t.Errorf("file for %s should exist: %v, got error %v", ifname, exists, err)
+		}
+	}
+
+	pruned, err = PruneSystemdNetworkd(testDir, []string{"eth_a"})
+	if err != nil || pruned {
+		t.Errorf("nothing should have been pruned on the second run: %v", err)
+	}
+}
+
+func TestPersistNetworkdReload(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Errorf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	reloads := 0
+	origReload := networkdReload
+	networkdReload = func() error {
+		reloads++
+		return nil
+	}
+	defer func() { networkdReload = origReload }()
+
+	config := &cmdConfig{networkd: testDir, mode: L3, mtu: 8000}
+
+	nwconfigs, _ := fakesystemdnetworkdconfigs()
+
+	state := &hostState{}
+	if err := persistNetworkd(config, nwconfigs, state); err != nil {
+		t.Fatalf("could not persist networkd configuration: %v", err)
+	}
+
+	if reloads != 1 {
+		t.Errorf("expected one reload, got %d", reloads)
+	}
+
+	if len(state.Files) != 2 {
+		t.Errorf("expected two files in the host state, got %v", state.Files)
+	}
+
+	if err := persistNetworkd(config, nwconfigs, state); err != nil {
+		t.Fatalf("could not persist networkd configuration: %v", err)
+	}
+
+	if reloads != 1 {
+		t.Errorf("unchanged files should not trigger a reload, got %d reloads", reloads)
+	}
+
+	delete(nwconfigs, "eth_c")
+	if err := persistNetworkd(config, nwconfigs, state); err != nil {
+		t.Fatalf("could not persist networkd configuration: %v", err)
+	}
+
+	if reloads != 2 {
+		t.Errorf("pruning a file should trigger a reload, got %d reloads", reloads)
 	}
 }
 
@@ -154,7 +315,7 @@ func TestSystemdNetworkdConfigNoDir(t *testing.T) {
 
 		attemptedToConfigureNetworkd = true
 
-		_, err := WriteSystemdNetworkd(confDir, map[string]*networkConfiguration{iface: nwconfig})
+		_, _, err := WriteSystemdNetworkd(confDir, map[string]*networkConfiguration{iface: nwconfig}, L3, 0)
 		if err == nil {
 			t.Errorf("wrote config file %s when directory is missing: %v", configfile, err)
 		}
@@ -0,0 +1,187 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+	"k8s.io/klog/v2"
+)
+
+const (
+	icmpProtocol = 1
+	pingPayload  = "intel-network-operator"
+)
+
+// pingFn sends an ICMP echo request from src to dst through interface
+// ifname and waits for the reply. Tests replace it.
+var pingFn = icmpPing
+
+func icmpPing(ifname string, src, dst net.IP, seq int, timeout time.Duration) error {
+	lc := net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			var bindErr error
+
+			if err := c.Control(func(fd uintptr) {
+				bindErr = unix.BindToDevice(int(fd), ifname)
+			}); err != nil {
+				return err
+			}
+
+			return bindErr
+		},
+	}
+
+	conn, err := lc.ListenPacket(context.Background(), "ip4:icmp", src.String())
+	if err != nil {
+		return fmt.Errorf("could not open ICMP socket on '%s': %v", ifname, err)
+	}
+	defer conn.Close()
+
+	id := os.Getpid() & 0xffff
+	msg := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte(pingPayload)},
+	}
+
+	request, err := msg.Marshal(nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := conn.WriteTo(request, &net.IPAddr{IP: dst}); err != nil {
+		return fmt.Errorf("could not send ICMP echo to %s on '%s': %v", dst, ifname, err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 1500)
+
+	for {
+		n, peer, err := conn.ReadFrom(reply)
+		if err != nil {
+			return fmt.Errorf("no ICMP echo reply from %s on '%s': %v", dst, ifname, err)
+		}
+
+		if peerAddr, ok := peer.(*net.IPAddr); !ok || !peerAddr.IP.Equal(dst) {
+			continue
+		}
+
+		parsed, err := icmp.ParseMessage(icmpProtocol, reply[:n])
+		if err != nil || parsed.Type != ipv4.ICMPTypeEchoReply {
+			continue
+		}
+
+		if echo, ok := parsed.Body.(*icmp.Echo); ok && echo.ID == id && echo.Seq == seq {
+			return nil
+		}
+	}
+}
+
+// verifyTargets returns the addresses to check for an interface: the LLDP
+// peer and the additional remote targets.
+func verifyTargets(config *cmdConfig, nwconfig *networkConfiguration) []net.IP {
+	targets := []net.IP{*nwconfig.lldpPeer}
+
+	if config.verifyTargets == "" {
+		return targets
+	}
+
+	for _, t := range strings.Split(config.verifyTargets, ",") {
+		if ip := net.ParseIP(strings.TrimSpace(t)); ip != nil {
+			targets = append(targets, ip)
+		}
+	}
+
+	return targets
+}
+
+func verifyInterface(config *cmdConfig, nwconfig *networkConfiguration) error {
+	ifname := nwconfig.link.Attrs().Name
+
+	for _, target := range verifyTargets(config, nwconfig) {
+		var err error
+
+		for attempt := range config.verifyAttempts {
+			if err = pingFn(ifname, *nwconfig.localAddr, target, attempt, config.verifyTimeout); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		klog.V(3).Infof("Interface '%s' reached %s", ifname, target)
+	}
+
+	return nil
+}
+
+// verifyConnectivity checks in parallel that the configured interfaces reach
+// their LLDP peer and the remote targets. Returns the number of reachable
+// interfaces.
+func verifyConnectivity(config *cmdConfig, networkConfigs map[string]*networkConfiguration) int {
+	var wg sync.WaitGroup
+
+	klog.Infof("Verifying connectivity...")
+
+	for _, nwconfig := range networkConfigs {
+		nwconfig.unreachable = false
+
+		if !nwconfig.configured || nwconfig.localAddr == nil || nwconfig.lldpPeer == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := verifyInterface(config, nwconfig); err != nil {
+				klog.Warningf("Interface '%s' failed verification: %v", nwconfig.link.Attrs().Name, err)
+
+				nwconfig.unreachable = true
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	reachable := 0
+
+	for _, nwconfig := range networkConfigs {
+		if nwconfig.ready() {
+			reachable++
+		}
+	}
+
+	klog.Infof("%d of %d interfaces passed verification", reachable, len(networkConfigs))
+
+	return reachable
+}
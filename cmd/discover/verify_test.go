@@ -0,0 +1,123 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerifyConnectivity(t *testing.T) {
+	var mutex sync.Mutex
+
+	pinged := map[string][]string{}
+
+	pingFn = func(ifname string, src, dst net.IP, seq int, timeout time.Duration) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		pinged[ifname] = append(pinged[ifname], dst.String())
+
+		// eth_c peer answers only to the second attempt, remote target
+		// never from eth_a
+		if ifname == "eth_c" && seq == 0 {
+			return fmt.Errorf("timeout")
+		}
+		if ifname == "eth_a" && dst.String() == "10.210.0.1" {
+			return fmt.Errorf("timeout")
+		}
+
+		return nil
+	}
+	defer func() { pingFn = icmpPing }()
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	lldpResults(nwconfigs)
+
+	for _, nwconfig := range nwconfigs {
+		nwconfig.configured = nwconfig.localAddr != nil
+	}
+
+	config := &cmdConfig{verifyAttempts: 2, verifyTimeout: time.Second}
+
+	if reachable := verifyConnectivity(config, nwconfigs); reachable != 2 {
+		t.Errorf("expected 2 reachable interfaces, got %d", reachable)
+	}
+	if _, exists := pinged["eth_b"]; exists {
+		t.Errorf("unconfigured eth_b should not have been verified")
+	}
+	if len(pinged["eth_c"]) != 2 {
+		t.Errorf("expected eth_c peer to be retried, got %v", pinged["eth_c"])
+	}
+
+	config.verifyTargets = "10.210.0.1"
+	pinged = map[string][]string{}
+
+	if reachable := verifyConnectivity(config, nwconfigs); reachable != 1 {
+		t.Errorf("expected 1 reachable interface, got %d", reachable)
+	}
+	if !nwconfigs["eth_a"].unreachable || nwconfigs["eth_a"].ready() {
+		t.Errorf("eth_a should have been marked unreachable")
+	}
+	if len(pinged["eth_a"]) != 3 {
+		t.Errorf("expected peer and two attempts for target from eth_a, got %v", pinged["eth_a"])
+	}
+}
+
+func TestVerifyConfigurationRollback(t *testing.T) {
+	pingFn = func(ifname string, src, dst net.IP, seq int, timeout time.Duration) error {
+		return fmt.Errorf("timeout")
+	}
+	defer func() { pingFn = icmpPing }()
+
+	nwconfigs := getFakeNetworkDataConfigs()
+	lldpResults(nwconfigs)
+
+	for _, nwconfig := range nwconfigs {
+		nwconfig.configured = nwconfig.localAddr != nil
+	}
+
+	for _, policy := range []string{applyBestEffort, applyStrict} {
+		testDir := t.TempDir()
+		persisted := filepath.Join(testDir, "eth_a.network")
+
+		if err := os.WriteFile(persisted, []byte("[Match]\n"), 0644); err != nil {
+			t.Fatalf("cannot write test file: %v", err)
+		}
+
+		config := &cmdConfig{applyPolicy: policy, minReady: "1", verifyAttempts: 1, verifyTimeout: time.Second}
+		state := &hostState{Version: stateVersion, Interfaces: map[string]*interfaceState{}, Files: []string{persisted}}
+
+		if err := verifyConfiguration(config, nwconfigs, state); err == nil {
+			t.Errorf("%s: expected a verification error", policy)
+		}
+
+		_, err := os.Stat(persisted)
+
+		switch {
+		case policy == applyStrict && err == nil:
+			t.Errorf("%s: the persisted configuration should have been rolled back", policy)
+		case policy == applyBestEffort && err != nil:
+			t.Errorf("%s: the persisted configuration should have been kept: %v", policy, err)
+		}
+	}
+}
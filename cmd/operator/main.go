@@ -19,6 +19,7 @@ import (
 	"flag"
 	"os"
 	"slices"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -36,6 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	networkv1beta1 "github.com/intel/network-operator/api/v1beta1"
 	"github.com/intel/network-operator/internal/controller"
 
 	//+kubebuilder:scaffold:imports
@@ -58,6 +60,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(networkv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(networkv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -92,6 +95,8 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var manageNFDRules bool
+	var gaudiDeviceIDs string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -102,6 +107,10 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&manageNFDRules, "manage-nfd-rules", true,
+		"If set, a NodeFeatureRule labeling the Gaudi nodes is created for every policy when NFD is installed")
+	flag.StringVar(&gaudiDeviceIDs, "gaudi-device-ids", "",
+		"Comma separated list of additional Gaudi PCI device IDs for the NodeFeatureRules, e.g. 1070")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -200,10 +209,19 @@ func main() {
 		setupLog.Info("Detected OpenShift environment")
 	}
 
+	deviceIDs := []string{}
+	for _, id := range strings.Split(gaudiDeviceIDs, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			deviceIDs = append(deviceIDs, strings.ToLower(id))
+		}
+	}
+
 	if err = (&controller.NetworkClusterPolicyReconciler{
-		Client:    mgr.GetClient(),
-		Scheme:    mgr.GetScheme(),
-		Namespace: ns,
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		Namespace:              ns,
+		ManageNodeFeatureRules: manageNFDRules,
+		GaudiDeviceIDs:         deviceIDs,
 	}).SetupWithManager(mgr, isInOpenShift); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "NetworkClusterPolicy")
 		os.Exit(1)
@@ -213,6 +231,10 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "NetworkClusterPolicy")
 			os.Exit(1)
 		}
+		if err = (&networkv1beta1.NetworkClusterPolicy{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "NetworkClusterPolicy", "version", "v1beta1")
+			os.Exit(1)
+		}
 	}
 	//+kubebuilder:scaffold:builder
 
@@ -18,6 +18,7 @@ import (
 	_ "embed"
 
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	"sigs.k8s.io/yaml"
@@ -26,6 +27,9 @@ import (
 //go:embed base/daemonset.yaml
 var contentGaudiDiscoveryDs []byte
 
+//go:embed base/cleanup-job.yaml
+var contentGaudiCleanupJob []byte
+
 //go:embed generic/linkdiscovery-serviceaccount.yaml
 var contentLinkDiscoveryServiceAccount []byte
 
@@ -36,6 +40,10 @@ func GaudiDiscoveryDaemonSet() *apps.DaemonSet {
 	return getDaemonset(contentGaudiDiscoveryDs).DeepCopy()
 }
 
+func GaudiCleanupJob() *batch.Job {
+	return getJob(contentGaudiCleanupJob).DeepCopy()
+}
+
 func GaudiLinkDiscoveryServiceAccount() *core.ServiceAccount {
 	return getServiceAccount(contentLinkDiscoveryServiceAccount).DeepCopy()
 }
@@ -56,6 +64,18 @@ func getDaemonset(content []byte) *apps.DaemonSet {
 	return &result
 }
 
+// getJob unmarshalls yaml content into a Job object.
+func getJob(content []byte) *batch.Job {
+	var result batch.Job
+
+	err := yaml.Unmarshal(content, &result)
+	if err != nil {
+		panic(err)
+	}
+
+	return &result
+}
+
 // getServiceAccount unmarshalls yaml content into a ServiceAccount object.
 func getServiceAccount(content []byte) *core.ServiceAccount {
 	var result core.ServiceAccount
@@ -25,6 +25,13 @@ func TestGetDaemonset(t *testing.T) {
 	}
 }
 
+func TestGetCleanupJob(t *testing.T) {
+	job := GaudiCleanupJob()
+	if job == nil {
+		t.Error("expected to receive a valid job")
+	}
+}
+
 func TestGaudiServiceAccount(t *testing.T) {
 	sa := GaudiLinkDiscoveryServiceAccount()
 	if sa == nil {
@@ -0,0 +1,43 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nfd
+
+import (
+	_ "embed"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Labels of the Gaudi device rule.
+const (
+	GaudiDeviceLabel = "intel.feature.node.kubernetes.io/gaudi"
+	GaudiReadyLabel  = "intel.feature.node.kubernetes.io/gaudi-ready"
+)
+
+//go:embed gaudi-device-rule.yaml
+var contentGaudiDeviceRule []byte
+
+// GaudiDeviceRule returns the NodeFeatureRule labeling the Gaudi nodes. It is
+// unstructured as the NFD API types are not a dependency of the operator.
+func GaudiDeviceRule() *unstructured.Unstructured {
+	var result unstructured.Unstructured
+
+	if err := yaml.Unmarshal(contentGaudiDeviceRule, &result.Object); err != nil {
+		panic(err)
+	}
+
+	return &result
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nfd
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGaudiDeviceRule(t *testing.T) {
+	rule := GaudiDeviceRule()
+	if rule.GetKind() != "NodeFeatureRule" {
+		t.Errorf("expected a NodeFeatureRule, got %q", rule.GetKind())
+	}
+
+	rules, _, _ := unstructured.NestedSlice(rule.Object, "spec", "rules")
+
+	labels := map[string]bool{}
+	for _, r := range rules {
+		ruleLabels, _, _ := unstructured.NestedStringMap(r.(map[string]interface{}), "labels")
+		for label := range ruleLabels {
+			labels[label] = true
+		}
+	}
+
+	for _, label := range []string{GaudiDeviceLabel, GaudiReadyLabel} {
+		if !labels[label] {
+			t.Errorf("expected a rule for the label %s", label)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	networkv1beta1 "github.com/intel/network-operator/api/v1beta1"
+	discovery "github.com/intel/network-operator/config/discovery"
+)
+
+const (
+	// policyFinalizer keeps the policy around until its host changes have
+	// been reverted on the nodes
+	policyFinalizer = "intel.com/network-cleanup"
+
+	// cleanup jobs of the current deletion are found with this label
+	policyUIDLabel = "intel.com/networkclusterpolicy-uid"
+
+	// nodes where the node agent of a policy has run get this annotation
+	// with the policy UID appended, so that they are cleaned up even after
+	// leaving the targeted nodes
+	agentNodeAnnotationPrefix = "intel.com/agent-"
+
+	// the policy is released after this even if the cleanup has not finished
+	cleanupTimeout      = 5 * time.Minute
+	cleanupPollInterval = 10 * time.Second
+
+	reasonCleanupComplete = "CleanupComplete"
+	reasonCleanupTimedOut = "CleanupTimedOut"
+	reasonCleanupFailed   = "CleanupFailed"
+)
+
+// cleanupJobName is unique per policy instance and node so that a job is
+// never created twice, even when the cache has not seen it yet.
+func cleanupJobName(cr *networkv1alpha1.NetworkClusterPolicy, nodeName string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(string(cr.UID) + "/" + nodeName))
+
+	prefix := cr.Name
+	if len(prefix) > 40 {
+		prefix = prefix[:40]
+	}
+
+	return fmt.Sprintf("%s-cleanup-%08x", prefix, h.Sum32())
+}
+
+func agentNodeAnnotation(cr *networkv1alpha1.NetworkClusterPolicy) string {
+	return agentNodeAnnotationPrefix + string(cr.UID)
+}
+
+// recordAgentNodes marks the nodes running the node agent of the policy, as
+// the agent may change their host configuration.
+func (r *NetworkClusterPolicyReconciler) recordAgentNodes(ctx context.Context, cr *networkv1alpha1.NetworkClusterPolicy, ds *apps.DaemonSet) error {
+	agents, err := r.agentPods(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	key := agentNodeAnnotation(cr)
+
+	for nodeName := range agents {
+		var node v1.Node
+		if err := r.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return err
+		}
+
+		if _, ok := node.Annotations[key]; ok {
+			continue
+		}
+
+		patch := client.MergeFrom(node.DeepCopy())
+		metav1.SetMetaDataAnnotation(&node.ObjectMeta, key, cr.Name)
+
+		if err := r.Patch(ctx, &node, patch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forgetAgentNodes removes the agent node annotations of a released policy.
+func (r *NetworkClusterPolicyReconciler) forgetAgentNodes(ctx context.Context, cr *networkv1alpha1.NetworkClusterPolicy) error {
+	var nodes v1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return err
+	}
+
+	key := agentNodeAnnotation(cr)
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+
+		if _, ok := node.Annotations[key]; !ok {
+			continue
+		}
+
+		patch := client.MergeFrom(node.DeepCopy())
+		delete(node.Annotations, key)
+
+		if err := r.Patch(ctx, node, patch); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cleanupNodes returns the names of the nodes where the node agent of the
+// policy has run. The targeted nodes are included for the agents that ran
+// before the nodes were recorded.
+func cleanupNodes(cr *networkv1alpha1.NetworkClusterPolicy, nodes []v1.Node) []string {
+	selector, err := cr.Spec.TargetNodeSelector()
+	if err != nil {
+		selector = labels.Nothing()
+	}
+
+	key := agentNodeAnnotation(cr)
+	names := []string{}
+
+	for _, node := range nodes {
+		if _, ok := node.Annotations[key]; ok || selector.Matches(labels.Set(node.Labels)) {
+			names = append(names, node.Name)
+		}
+	}
+
+	return names
+}
+
+// createCleanupJobs starts a job on every node where the node agent of the
+// policy has run to revert its host changes, unless the node already has one.
+// The jobs are owned by the policy, so they are removed with it. Returns the
+// number of jobs created.
+func (r *NetworkClusterPolicyReconciler) createCleanupJobs(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy, jobs []batch.Job) (int, error) {
+	var nodes v1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return 0, err
+	}
+
+	existing := map[string]bool{}
+	for _, job := range jobs {
+		existing[job.Spec.Template.Spec.NodeName] = true
+	}
+
+	// Pod spec is the same as in the DaemonSet to get identical mounts
+	ds := discovery.GaudiDiscoveryDaemonSet()
+	updateGaudiScaleOutDaemonSet(ds, cr, r.Namespace)
+
+	if r.isOpenShift {
+		ds.Spec.Template.Spec.ServiceAccountName = cr.Name + "-sa"
+	}
+
+	args := []string{"cleanup"}
+	if cr.Spec.LogLevel > 0 {
+		args = append(args, fmt.Sprintf("--v=%d", cr.Spec.LogLevel))
+	}
+
+	created := 0
+
+	for _, nodeName := range cleanupNodes(cr, nodes.Items) {
+		if existing[nodeName] {
+			continue
+		}
+
+		job := discovery.GaudiCleanupJob()
+		job.Name = cleanupJobName(cr, nodeName)
+		job.Namespace = r.Namespace
+		job.Labels[policyNameLabel] = cr.Name
+		job.Labels[policyUIDLabel] = string(cr.UID)
+
+		restartPolicy := job.Spec.Template.Spec.RestartPolicy
+
+		job.Spec.Template.Spec = *ds.Spec.Template.Spec.DeepCopy()
+		job.Spec.Template.Spec.RestartPolicy = restartPolicy
+		job.Spec.Template.Spec.NodeName = nodeName
+		job.Spec.Template.Spec.NodeSelector = nil
+		job.Spec.Template.Spec.Affinity = nil
+		job.Spec.Template.Spec.Containers[0].Args = args
+		// the DaemonSet pod may still hold the host ports, and cleanup
+		// does not serve the probes
+		job.Spec.Template.Spec.Containers[0].Ports = nil
+		job.Spec.Template.Spec.Containers[0].LivenessProbe = nil
+		job.Spec.Template.Spec.Containers[0].ReadinessProbe = nil
+
+		if err := ctrl.SetControllerReference(cr, job, r.Scheme); err != nil {
+			return created, err
+		}
+
+		if err := r.Create(ctx, job); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				continue
+			}
+
+			return created, err
+		}
+
+		log.Info("Cleanup job created", "node", nodeName)
+
+		created++
+	}
+
+	return created, nil
+}
+
+func jobFinished(job *batch.Job) (finished, failed bool) {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != v1.ConditionTrue {
+			continue
+		}
+
+		switch cond.Type {
+		case batch.JobComplete:
+			return true, false
+		case batch.JobFailed:
+			return true, true
+		}
+	}
+
+	return false, false
+}
+
+func (r *NetworkClusterPolicyReconciler) setCleanupStatus(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy, state string, errors []networkv1beta1.PolicyError) {
+	if cr.Status.State == state && len(cr.Status.Errors) == len(errors) {
+		return
+	}
+
+	cr.Status.State = state
+
+	if err := r.writeStatus(ctx, cr, errors); err != nil && !apierrors.IsConflict(err) && !apierrors.IsNotFound(err) {
+		log.Error(err, "unable to update cleanup status")
+	}
+}
+
+// teardown reverts the host changes of the policy. The DaemonSet is removed
+// first so that it does not reapply the configuration, then a cleanup job is
+// run on every targeted node. Returns true once all the jobs have finished.
+func (r *NetworkClusterPolicyReconciler) teardown(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy) (bool, error) {
+	if cr.Spec.ConfigurationType != gaudiScaleOutSelection {
+		return true, nil
+	}
+
+	var daemonSets apps.DaemonSetList
+	if err := r.List(ctx, &daemonSets, client.InNamespace(r.Namespace), client.MatchingFields{ownerKey: cr.Name}); err != nil {
+		return false, err
+	}
+
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+		if !ds.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		log.Info("Deleting DaemonSet before cleanup", "DaemonSet", ds.Name)
+
+		if err := r.Delete(ctx, ds, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+	}
+
+	var pods v1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(r.Namespace), client.MatchingFields{ownerKey: cr.Name}); err != nil {
+		return false, err
+	}
+
+	if len(daemonSets.Items) > 0 || len(pods.Items) > 0 {
+		r.setCleanupStatus(ctx, log, cr, fmt.Sprintf("Cleaning up: stopping %d discovery pods", len(pods.Items)), []networkv1beta1.PolicyError{})
+
+		return false, nil
+	}
+
+	var jobs batch.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(r.Namespace),
+		client.MatchingLabels{policyNameLabel: cr.Name, policyUIDLabel: string(cr.UID)}); err != nil {
+		return false, err
+	}
+
+	created, err := r.createCleanupJobs(ctx, log, cr, jobs.Items)
+	if err != nil {
+		return false, err
+	}
+
+	finished := 0
+	errors := []networkv1beta1.PolicyError{}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+
+		done, failed := jobFinished(job)
+		if done {
+			finished++
+		}
+
+		if failed {
+			node := job.Spec.Template.Spec.NodeName
+			errors = append(errors, networkv1beta1.PolicyError{Node: node, Message: fmt.Sprintf("cleanup failed on node %s", node)})
+		}
+	}
+
+	slices.SortFunc(errors, func(a, b networkv1beta1.PolicyError) int {
+		return strings.Compare(a.Node, b.Node)
+	})
+
+	r.setCleanupStatus(ctx, log, cr, fmt.Sprintf("Cleaning up: %d of %d nodes done", finished, len(jobs.Items)+created), errors)
+
+	// newly created jobs are checked in the next round
+	return created == 0 && finished == len(jobs.Items), nil
+}
+
+// finalize runs the teardown of a deleted policy and removes the finalizer
+// once the teardown has finished or timed out.
+func (r *NetworkClusterPolicyReconciler) finalize(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(cr, policyFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	done, err := r.teardown(ctx, log, cr)
+	if err != nil {
+		log.Error(err, "cleanup failed")
+		r.recorder.Eventf(cr, v1.EventTypeWarning, reasonCleanupFailed, "Cleanup failed: %v", err)
+	}
+
+	elapsed := time.Since(cr.DeletionTimestamp.Time)
+
+	switch {
+	case done && len(cr.Status.Errors) == 0:
+		r.recorder.Event(cr, v1.EventTypeNormal, reasonCleanupComplete, "Host configuration reverted on all the targeted nodes")
+	case done:
+		r.recorder.Eventf(cr, v1.EventTypeWarning, reasonCleanupFailed, "Cleanup failed on %d nodes", len(cr.Status.Errors))
+	case elapsed >= cleanupTimeout:
+		log.Info("Cleanup timed out, releasing the policy", "elapsed", elapsed)
+		r.recorder.Eventf(cr, v1.EventTypeWarning, reasonCleanupTimedOut, "Cleanup did not finish in %s", cleanupTimeout)
+	case err != nil:
+		return ctrl.Result{}, err
+	default:
+		return ctrl.Result{RequeueAfter: cleanupPollInterval}, nil
+	}
+
+	// nothing gets the nodes ready any more
+	if err := r.syncReadinessTaints(ctx, log, cr, nil); err != nil {
+		log.Error(err, "unable to remove the readiness taints")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncNodeConditions(ctx, log, cr, nil); err != nil {
+		log.Error(err, "unable to remove the node conditions")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.forgetAgentNodes(ctx, cr); err != nil {
+		log.Error(err, "unable to remove the agent node annotations")
+
+		return ctrl.Result{}, err
+	}
+
+	controllerutil.RemoveFinalizer(cr, policyFinalizer)
+
+	if err := r.Update(ctx, cr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	forgetPolicyMetrics(cr.Name)
+	nodeFailures.forget(cr.Name)
+
+	return ctrl.Result{}, nil
+}
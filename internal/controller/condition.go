@@ -0,0 +1,220 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+)
+
+//+kubebuilder:rbac:groups="",resources=nodes/status,verbs=get;patch;update
+
+const (
+	// the scale-out state of the targeted nodes is shown as this node condition
+	scaleOutConditionType v1.NodeConditionType = "GaudiScaleOutNetworkReady"
+
+	conditionReasonReady           = "NetworkReady"
+	conditionReasonNotReady        = "NetworkNotReady"
+	conditionReasonFailed          = "ConfigurationFailed"
+	conditionReasonAgentNotRunning = "AgentNotRunning"
+)
+
+// agentPods returns the node agent pods of the DaemonSet by their node.
+func (r *NetworkClusterPolicyReconciler) agentPods(ctx context.Context, ds *apps.DaemonSet) (map[string]*v1.Pod, error) {
+	agents := map[string]*v1.Pod{}
+
+	if ds == nil {
+		return agents, nil
+	}
+
+	var pods v1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(ds.Namespace), client.MatchingFields{ownerKey: ds.Name}); err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+
+		if pod.Spec.NodeName != "" && pod.DeletionTimestamp.IsZero() {
+			agents[pod.Spec.NodeName] = pod
+		}
+	}
+
+	return agents, nil
+}
+
+// scaleOutCondition describes the scale-out state of a node configured by
+// the node agent pod of the policy.
+func scaleOutCondition(policy string, pod *v1.Pod, now time.Time) v1.NodeCondition {
+	cond := v1.NodeCondition{
+		Type:    scaleOutConditionType,
+		Status:  v1.ConditionFalse,
+		Reason:  conditionReasonNotReady,
+		Message: fmt.Sprintf("Node agent of policy %s is configuring the scale-out interfaces", policy),
+	}
+
+	switch {
+	case pod == nil:
+		cond.Reason = conditionReasonAgentNotRunning
+		cond.Message = fmt.Sprintf("Node agent of policy %s is not running on the node", policy)
+	case podReady(pod):
+		cond.Status = v1.ConditionTrue
+		cond.Reason = conditionReasonReady
+		cond.Message = fmt.Sprintf("Scale-out interfaces configured by policy %s", policy)
+	default:
+		if reason := podFailure(pod, now); reason != "" {
+			cond.Reason = conditionReasonFailed
+			cond.Message = fmt.Sprintf("Node agent of policy %s failed: %s", policy, reason)
+		}
+	}
+
+	return cond
+}
+
+// setNodeCondition sets the scale-out condition of the node, and tells if it
+// changed. Only the changes of the status and the reason count, so that the
+// details in the failure messages do not cause a write on every reconcile.
+// The transition time only changes with the status.
+func setNodeCondition(node *v1.Node, cond v1.NodeCondition, now time.Time) bool {
+	cond.LastHeartbeatTime = metav1.NewTime(now)
+	cond.LastTransitionTime = cond.LastHeartbeatTime
+
+	idx := slices.IndexFunc(node.Status.Conditions, func(c v1.NodeCondition) bool { return c.Type == cond.Type })
+	if idx < 0 {
+		node.Status.Conditions = append(node.Status.Conditions, cond)
+
+		return true
+	}
+
+	old := &node.Status.Conditions[idx]
+
+	if old.Status == cond.Status && old.Reason == cond.Reason {
+		return false
+	}
+
+	if old.Status == cond.Status {
+		cond.LastTransitionTime = old.LastTransitionTime
+	}
+
+	*old = cond
+
+	return true
+}
+
+func removeNodeCondition(node *v1.Node) bool {
+	conditions := slices.DeleteFunc(slices.Clone(node.Status.Conditions), func(c v1.NodeCondition) bool {
+		return c.Type == scaleOutConditionType
+	})
+
+	if len(conditions) == len(node.Status.Conditions) {
+		return false
+	}
+
+	node.Status.Conditions = conditions
+
+	return true
+}
+
+// otherTargets returns the node selectors of the other policies, which keep
+// the condition up to date on their nodes.
+func (r *NetworkClusterPolicyReconciler) otherTargets(ctx context.Context, cr *networkv1alpha1.NetworkClusterPolicy) ([]labels.Selector, error) {
+	var policies networkv1alpha1.NetworkClusterPolicyList
+	if err := r.List(ctx, &policies); err != nil {
+		return nil, err
+	}
+
+	selectors := []labels.Selector{}
+
+	for _, policy := range policies.Items {
+		if policy.Name == cr.Name || !policy.DeletionTimestamp.IsZero() ||
+			policy.Spec.ConfigurationType != cr.Spec.ConfigurationType {
+			continue
+		}
+
+		if selector, err := policy.Spec.TargetNodeSelector(); err == nil {
+			selectors = append(selectors, selector)
+		}
+	}
+
+	return selectors, nil
+}
+
+// syncNodeConditions updates the scale-out condition of the targeted nodes,
+// and removes it from the nodes no policy targets any more. When the policy
+// is being deleted, none of the nodes are targeted by it.
+func (r *NetworkClusterPolicyReconciler) syncNodeConditions(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy, ds *apps.DaemonSet) error {
+	var nodes v1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return err
+	}
+
+	agents, err := r.agentPods(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	others, err := r.otherTargets(ctx, cr)
+	if err != nil {
+		return err
+	}
+
+	selector, err := cr.Spec.TargetNodeSelector()
+	if err != nil || !cr.DeletionTimestamp.IsZero() {
+		selector = labels.Nothing()
+	}
+
+	now := time.Now()
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		original := node.DeepCopy()
+		nodeLabels := labels.Set(node.Labels)
+
+		var changed bool
+
+		switch {
+		case selector.Matches(nodeLabels):
+			changed = setNodeCondition(node, scaleOutCondition(cr.Name, agents[node.Name], now), now)
+		case slices.ContainsFunc(others, func(s labels.Selector) bool { return s.Matches(nodeLabels) }):
+			continue
+		default:
+			changed = removeNodeCondition(node)
+		}
+
+		if !changed {
+			continue
+		}
+
+		// conditions are merged by their type, leaving the ones of the kubelet alone
+		if err := r.Status().Patch(ctx, node, client.StrategicMergeFrom(original)); err != nil {
+			return err
+		}
+
+		log.Info("Updated the scale-out node condition", "node", node.Name)
+	}
+
+	return nil
+}
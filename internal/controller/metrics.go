@@ -0,0 +1,194 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	apps "k8s.io/api/apps/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "intel_network_operator"
+
+	reconcileSuccess = "success"
+	reconcileError   = "error"
+	reconcileRequeue = "requeue"
+)
+
+var (
+	policyTargets = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_targets",
+		Help:      "Number of nodes targeted by the policy.",
+	}, []string{"policy"})
+
+	policyReadyNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_ready_nodes",
+		Help:      "Number of targeted nodes where the configuration is ready.",
+	}, []string{"policy"})
+
+	policyFailingNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_failing_nodes",
+		Help:      "Number of targeted nodes where the configuration is not available.",
+	}, []string{"policy"})
+
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_reconcile_total",
+		Help:      "Number of policy reconciliations by result and reason.",
+	}, []string{"policy", "result", "reason"})
+
+	daemonSetUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_daemonset_updates_total",
+		Help:      "Number of DaemonSet updates done for the policy.",
+	}, []string{"policy"})
+
+	rolloutStartTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_rollout_start_time_seconds",
+		Help:      "Start time of the ongoing rollout of the policy since unix epoch in seconds.",
+	}, []string{"policy"})
+
+	rolloutDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_rollout_duration_seconds",
+		Help:      "Time from a DaemonSet create or update until all the targeted nodes are ready.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+	}, []string{"policy"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(policyTargets, policyReadyNodes, policyFailingNodes,
+		reconcileTotal, daemonSetUpdates, rolloutStartTime, rolloutDuration)
+}
+
+// rolloutTracker remembers when the rollout of each policy started.
+type rolloutTracker struct {
+	mutex  sync.Mutex
+	starts map[string]time.Time
+	now    func() time.Time
+}
+
+var rollouts = &rolloutTracker{
+	starts: map[string]time.Time{},
+	now:    time.Now,
+}
+
+// start records the start of a rollout unless one is already ongoing.
+func (t *rolloutTracker) start(policy string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, exists := t.starts[policy]; exists {
+		return
+	}
+
+	start := t.now()
+	t.starts[policy] = start
+
+	rolloutStartTime.WithLabelValues(policy).Set(float64(start.Unix()))
+}
+
+// restart records the start of a new rollout, dropping an ongoing one.
+func (t *rolloutTracker) restart(policy string) {
+	t.forget(policy)
+	t.start(policy)
+}
+
+// finish observes the duration of an ongoing rollout. Returns the duration
+// and whether there was a rollout to finish.
+func (t *rolloutTracker) finish(policy string) (time.Duration, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	start, exists := t.starts[policy]
+	if !exists {
+		return 0, false
+	}
+
+	delete(t.starts, policy)
+
+	duration := t.now().Sub(start)
+
+	rolloutDuration.WithLabelValues(policy).Observe(duration.Seconds())
+	rolloutStartTime.DeleteLabelValues(policy)
+
+	return duration, true
+}
+
+func (t *rolloutTracker) forget(policy string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.starts, policy)
+}
+
+func rolloutDone(ds *apps.DaemonSet) bool {
+	return ds.Status.ObservedGeneration >= ds.Generation &&
+		ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberReady == ds.Status.DesiredNumberScheduled
+}
+
+// recordPolicyStatus updates the node counts of the policy from its DaemonSet
+// and tracks the progress of the rollout. Returns the rollout duration and
+// true when this call completed the rollout.
+func recordPolicyStatus(policy string, ds *apps.DaemonSet) (time.Duration, bool) {
+	policyTargets.WithLabelValues(policy).Set(float64(ds.Status.DesiredNumberScheduled))
+	policyReadyNodes.WithLabelValues(policy).Set(float64(ds.Status.NumberReady))
+	policyFailingNodes.WithLabelValues(policy).Set(float64(ds.Status.NumberUnavailable))
+
+	if rolloutDone(ds) {
+		return rollouts.finish(policy)
+	}
+
+	rollouts.start(policy)
+
+	return 0, false
+}
+
+func recordReconcile(policy string, result ctrl.Result, err error, reason string) {
+	outcome := reconcileSuccess
+
+	if err != nil {
+		outcome = reconcileError
+	} else if result.Requeue || result.RequeueAfter > 0 {
+		outcome = reconcileRequeue
+	}
+
+	reconcileTotal.WithLabelValues(policy, outcome, reason).Inc()
+}
+
+// forgetPolicyMetrics removes the metrics of a deleted policy.
+func forgetPolicyMetrics(policy string) {
+	labels := prometheus.Labels{"policy": policy}
+
+	policyTargets.DeletePartialMatch(labels)
+	policyReadyNodes.DeletePartialMatch(labels)
+	policyFailingNodes.DeletePartialMatch(labels)
+	reconcileTotal.DeletePartialMatch(labels)
+	daemonSetUpdates.DeletePartialMatch(labels)
+	rolloutStartTime.DeletePartialMatch(labels)
+	rolloutDuration.DeletePartialMatch(labels)
+
+	rollouts.forget(policy)
+}
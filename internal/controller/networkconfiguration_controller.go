@@ -17,23 +17,36 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
 	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	networkv1beta1 "github.com/intel/network-operator/api/v1beta1"
 	discovery "github.com/intel/network-operator/config/discovery"
 )
 
@@ -41,10 +54,12 @@ import (
 //+kubebuilder:rbac:groups=intel.com,resources=networkclusterpolicies/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=intel.com,resources=networkclusterpolicies/finalizers,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;create;delete
+//+kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;create;update;delete
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;create;delete
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
-//+kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;patch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
 
 // NetworkClusterPolicyReconciler reconciles a NetworkClusterPolicy object
 type NetworkClusterPolicyReconciler struct {
@@ -52,6 +67,13 @@ type NetworkClusterPolicyReconciler struct {
 	Scheme      *runtime.Scheme
 	Namespace   string
 	isOpenShift bool
+	recorder    record.EventRecorder
+
+	// ManageNodeFeatureRules creates a Gaudi device rule for every policy
+	// when Node Feature Discovery is installed
+	ManageNodeFeatureRules bool
+	// GaudiDeviceIDs extends the device IDs of all the Gaudi device rules
+	GaudiDeviceIDs []string
 }
 
 const (
@@ -64,6 +86,45 @@ const (
 
 	gaudinetPathHost      = "/etc/habanalabs/gaudinet.json"
 	gaudinetPathContainer = "/host" + gaudinetPathHost
+
+	persistenceNetworkd = "systemd-networkd"
+	persistenceNM       = "NetworkManager"
+	persistenceNetplan  = "netplan"
+
+	networkdPathHost      = "/etc/systemd/network"
+	networkdPathContainer = "/host" + networkdPathHost
+
+	netplanPathHost      = "/etc/netplan"
+	netplanPathContainer = "/host" + netplanPathHost
+
+	// discover records the original host state here for the cleanup jobs
+	discoverStatePath = "/var/lib/intel-network-operator"
+
+	// discover serves the interface health metrics on this host port
+	discoverMetricsPort = 9112
+	// and the readiness and liveness probes on this one
+	discoverHealthPort = 9113
+
+	policyNameLabel = "intel.com/networkclusterpolicy"
+
+	// set by 'kubectl rollout restart' to the pod template
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+	// the pull secrets the operator added to the OpenShift service account
+	pullSecretsAnnotation = "intel.com/image-pull-secrets"
+
+	// discovery pods not ready for longer than this are reported as failing
+	failingNodeGracePeriod = 5 * time.Minute
+
+	// Event reasons
+	reasonDaemonSetCreated     = "DaemonSetCreated"
+	reasonDaemonSetUpdated     = "DaemonSetUpdated"
+	reasonDaemonSetFailed      = "DaemonSetFailed"
+	reasonCollateralCreated    = "OpenShiftCollateralCreated"
+	reasonCollateralFailed     = "OpenShiftCollateralFailed"
+	reasonInvalidConfiguration = "InvalidConfiguration"
+	reasonRolloutComplete      = "RolloutComplete"
+	reasonNodeFailed           = "NodeConfigurationFailed"
 )
 
 func addHostVolume(ds *apps.DaemonSet, volumeType v1.HostPathType, volumeName, hostPath, containerPath string) {
@@ -106,7 +167,73 @@ func addHostVolume(ds *apps.DaemonSet, volumeType v1.HostPathType, volumeName, h
 	}
 }
 
-func (r *NetworkClusterPolicyReconciler) createOpenShiftCollateral(ctx context.Context, log logr.Logger, parent metav1.Object, serviceAccountName string) {
+// syncPullSecrets makes the service account have the pull secrets of the
+// policy. The secrets added by the operator are recorded in an annotation, so
+// that the ones removed from the policy are removed from the service account
+// as well. Secrets added by others, like the OpenShift registry one, are left
+// in place. Returns whether the service account changed.
+func syncPullSecrets(sa *v1.ServiceAccount, secrets []v1.LocalObjectReference) bool {
+	previous := []string{}
+	if value := sa.Annotations[pullSecretsAnnotation]; value != "" {
+		previous = strings.Split(value, ",")
+	}
+
+	wanted := []string{}
+	for _, secret := range secrets {
+		wanted = append(wanted, secret.Name)
+	}
+
+	pullSecrets := slices.DeleteFunc(slices.Clone(sa.ImagePullSecrets), func(secret v1.LocalObjectReference) bool {
+		return slices.Contains(previous, secret.Name) && !slices.Contains(wanted, secret.Name)
+	})
+
+	for _, secret := range secrets {
+		if !slices.Contains(pullSecrets, secret) {
+			pullSecrets = append(pullSecrets, secret)
+		}
+	}
+
+	slices.Sort(wanted)
+	wanted = slices.Compact(wanted)
+
+	changed := !slices.Equal(pullSecrets, sa.ImagePullSecrets) || strings.Join(wanted, ",") != strings.Join(previous, ",")
+
+	sa.ImagePullSecrets = pullSecrets
+
+	if len(wanted) > 0 {
+		if sa.Annotations == nil {
+			sa.Annotations = map[string]string{}
+		}
+		sa.Annotations[pullSecretsAnnotation] = strings.Join(wanted, ",")
+	} else {
+		delete(sa.Annotations, pullSecretsAnnotation)
+	}
+
+	return changed
+}
+
+// syncServiceAccountPullSecrets updates the pull secrets of the policy in an
+// already created OpenShift service account.
+func (r *NetworkClusterPolicyReconciler) syncServiceAccountPullSecrets(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy) error {
+	var sa v1.ServiceAccount
+	if err := r.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: cr.Name + "-sa"}, &sa); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !syncPullSecrets(&sa, cr.Spec.GaudiScaleOut.ImagePullSecrets) {
+		return nil
+	}
+
+	if err := r.Update(ctx, &sa); err != nil {
+		return err
+	}
+
+	log.Info("Service account pull secrets updated", "name", sa.Name)
+
+	return nil
+}
+
+func (r *NetworkClusterPolicyReconciler) createOpenShiftCollateral(ctx context.Context, log logr.Logger, parent metav1.Object, serviceAccountName string, pullSecrets []v1.LocalObjectReference) {
 	if serviceAccountName == "" {
 		return
 	}
@@ -116,9 +243,12 @@ func (r *NetworkClusterPolicyReconciler) createOpenShiftCollateral(ctx context.C
 	sa := discovery.GaudiLinkDiscoveryServiceAccount()
 	sa.Name = serviceAccountName
 	sa.ObjectMeta.Namespace = r.Namespace
+	syncPullSecrets(sa, pullSecrets)
 
 	if err := ctrl.SetControllerReference(parent, sa, r.Scheme); err != nil {
 		log.Error(err, "unable to set controller reference (service account)")
+		r.recorder.Eventf(parent.(runtime.Object), v1.EventTypeWarning, reasonCollateralFailed,
+			"Unable to set controller reference for service account %s: %v", sa.Name, err)
 
 		return
 	}
@@ -126,6 +256,8 @@ func (r *NetworkClusterPolicyReconciler) createOpenShiftCollateral(ctx context.C
 	if err := r.Create(ctx, sa); err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			log.Error(err, "unable to create service account")
+			r.recorder.Eventf(parent.(runtime.Object), v1.EventTypeWarning, reasonCollateralFailed,
+				"Unable to create service account %s: %v", sa.Name, err)
 
 			return
 		}
@@ -146,6 +278,8 @@ func (r *NetworkClusterPolicyReconciler) createOpenShiftCollateral(ctx context.C
 
 	if err := ctrl.SetControllerReference(parent, rb, r.Scheme); err != nil {
 		log.Error(err, "unable to set controller reference (rolebinding)")
+		r.recorder.Eventf(parent.(runtime.Object), v1.EventTypeWarning, reasonCollateralFailed,
+			"Unable to set controller reference for role binding %s: %v", rb.Name, err)
 
 		return
 	}
@@ -153,12 +287,17 @@ func (r *NetworkClusterPolicyReconciler) createOpenShiftCollateral(ctx context.C
 	if err := r.Create(ctx, rb); err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			log.Error(err, "unable to create role binding")
+			r.recorder.Eventf(parent.(runtime.Object), v1.EventTypeWarning, reasonCollateralFailed,
+				"Unable to create role binding %s: %v", rb.Name, err)
 
 			return
 		}
 	}
 
 	log.Info("Role binding created", "name", rb.Name)
+
+	r.recorder.Eventf(parent.(runtime.Object), v1.EventTypeNormal, reasonCollateralCreated,
+		"Created service account %s and role binding %s", sa.Name, rb.Name)
 }
 
 func updateGaudiScaleOutDaemonSet(ds *apps.DaemonSet, netconf *networkv1alpha1.NetworkClusterPolicy, namespace string) {
@@ -173,6 +312,14 @@ func updateGaudiScaleOutDaemonSet(ds *apps.DaemonSet, netconf *networkv1alpha1.N
 		ds.Spec.Template.Spec.Containers[0].Image = netconf.Spec.GaudiScaleOut.Image
 	}
 
+	if len(netconf.Spec.GaudiScaleOut.PullPolicy) > 0 {
+		ds.Spec.Template.Spec.Containers[0].ImagePullPolicy = v1.PullPolicy(netconf.Spec.GaudiScaleOut.PullPolicy)
+	}
+
+	ds.Spec.Template.Spec.ImagePullSecrets = slices.Clone(netconf.Spec.GaudiScaleOut.ImagePullSecrets)
+
+	addHostVolume(ds, v1.HostPathDirectoryOrCreate, "discover-state", discoverStatePath, discoverStatePath)
+
 	args := []string{
 		"--configure=true", "--keep-running",
 		fmt.Sprintf("--mode=%s", netconf.Spec.GaudiScaleOut.Layer),
@@ -187,6 +334,14 @@ func updateGaudiScaleOutDaemonSet(ds *apps.DaemonSet, netconf *networkv1alpha1.N
 		args = append(args, fmt.Sprintf("--mtu=%d", netconf.Spec.GaudiScaleOut.MTU))
 	}
 
+	if len(netconf.Spec.GaudiScaleOut.ApplyPolicy) > 0 {
+		args = append(args, fmt.Sprintf("--apply-policy=%s", netconf.Spec.GaudiScaleOut.ApplyPolicy))
+	}
+
+	if netconf.Spec.GaudiScaleOut.MinReadyPorts != nil {
+		args = append(args, fmt.Sprintf("--min-ready-ports=%s", netconf.Spec.GaudiScaleOut.MinReadyPorts.String()))
+	}
+
 	if netconf.Spec.GaudiScaleOut.DisableNetworkManager {
 		args = append(args, "--disable-networkmanager")
 		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "var-run-dbus", "/var/run/dbus", "/var/run/dbus")
@@ -197,27 +352,200 @@ func updateGaudiScaleOutDaemonSet(ds *apps.DaemonSet, netconf *networkv1alpha1.N
 	case layerSelectionL3:
 		args = append(args, "--wait=90s", fmt.Sprintf("--gaudinet=%s", gaudinetPathContainer))
 
+		if v := netconf.Spec.GaudiScaleOut.Verification; v != nil {
+			args = append(args, "--verify")
+
+			if v.Timeout != nil {
+				args = append(args, fmt.Sprintf("--verify-timeout=%s", v.Timeout.Duration))
+			}
+			if v.Attempts > 0 {
+				args = append(args, fmt.Sprintf("--verify-attempts=%d", v.Attempts))
+			}
+			if len(v.Targets) > 0 {
+				args = append(args, fmt.Sprintf("--verify-targets=%s", strings.Join(v.Targets, ",")))
+			}
+		}
+
 		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "gaudinetpath", filepath.Dir(gaudinetPathHost), filepath.Dir(gaudinetPathContainer))
 	}
 
+	switch netconf.Spec.GaudiScaleOut.Persistence {
+	case persistenceNetworkd:
+		// networkd is told to reload the files over D-Bus
+		args = append(args, fmt.Sprintf("--systemd-networkd=%s", networkdPathContainer))
+		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "networkd", networkdPathHost, networkdPathContainer)
+		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "var-run-dbus", "/var/run/dbus", "/var/run/dbus")
+	case persistenceNM:
+		// profiles are saved by NetworkManager itself over D-Bus
+		args = append(args, "--networkmanager-profiles")
+		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "var-run-dbus", "/var/run/dbus", "/var/run/dbus")
+	case persistenceNetplan:
+		// the file is checked with netplan generate over D-Bus
+		args = append(args, fmt.Sprintf("--netplan=%s", netplanPathContainer))
+		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "netplan", netplanPathHost, netplanPathContainer)
+		addHostVolume(ds, v1.HostPathDirectoryOrCreate, "var-run-dbus", "/var/run/dbus", "/var/run/dbus")
+	}
+
+	args = append(args, fmt.Sprintf("--metrics-bind-address=:%d", discoverMetricsPort))
+	args = append(args, fmt.Sprintf("--health-probe-bind-address=:%d", discoverHealthPort))
+
 	ds.Spec.Template.Spec.Containers[0].Args = args
+
+	addHealthProbes(&ds.Spec.Template.Spec.Containers[0])
+
+	applyPodTemplateOverrides(ds, netconf.Spec.PodTemplate)
+
+	if netconf.Spec.ReadinessTaint != nil {
+		addReadinessToleration(ds, readinessTaintKey(netconf))
+	}
+
+	ds.Spec.Template.Spec.Affinity = policyAffinity(netconf)
+
+	ds.Spec.UpdateStrategy = updateStrategy(netconf.Spec.Rollout)
 }
 
-func (r *NetworkClusterPolicyReconciler) createGaudiScaleOutDaemonset(netconf client.Object, ctx context.Context, log logr.Logger) (ctrl.Result, error) {
-	ds := discovery.GaudiDiscoveryDaemonSet()
+// nodeSelectorRequirements converts a label selector to node selector
+// requirements. The operators of both have the same names.
+func nodeSelectorRequirements(selector *metav1.LabelSelector) []v1.NodeSelectorRequirement {
+	if selector == nil {
+		return nil
+	}
 
-	cr := netconf.(*networkv1alpha1.NetworkClusterPolicy)
+	reqs := []v1.NodeSelectorRequirement{}
 
-	log.Info("Creating Gaudi Scale-Out DaemonSet", "name", cr.Name)
+	keys := slices.Sorted(maps.Keys(selector.MatchLabels))
+	for _, key := range keys {
+		reqs = append(reqs, v1.NodeSelectorRequirement{
+			Key:      key,
+			Operator: v1.NodeSelectorOpIn,
+			Values:   []string{selector.MatchLabels[key]},
+		})
+	}
 
-	saName := ""
-	if r.isOpenShift {
-		saName = cr.Name + "-sa"
+	for _, expr := range selector.MatchExpressions {
+		reqs = append(reqs, v1.NodeSelectorRequirement{
+			Key:      expr.Key,
+			Operator: v1.NodeSelectorOperator(expr.Operator),
+			Values:   slices.Clone(expr.Values),
+		})
 	}
 
-	ds.Spec.Template.Spec.ServiceAccountName = saName
+	return reqs
+}
 
-	updateGaudiScaleOutDaemonSet(ds, cr, r.Namespace)
+// policyAffinity returns the pod affinity from the pod template overrides
+// with the node label selector of the policy added as a required node
+// affinity. The terms are ORed, so the requirements go to every term.
+func policyAffinity(cr *networkv1alpha1.NetworkClusterPolicy) *v1.Affinity {
+	var affinity *v1.Affinity
+	if cr.Spec.PodTemplate != nil && cr.Spec.PodTemplate.Affinity != nil {
+		affinity = cr.Spec.PodTemplate.Affinity.DeepCopy()
+	}
+
+	reqs := nodeSelectorRequirements(cr.Spec.NodeLabelSelector)
+	if len(reqs) == 0 {
+		return affinity
+	}
+
+	if affinity == nil {
+		affinity = &v1.Affinity{}
+	}
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &v1.NodeAffinity{}
+	}
+
+	required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil {
+		required = &v1.NodeSelector{}
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = required
+	}
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	}
+
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions, reqs...)
+	}
+
+	return affinity
+}
+
+// applyPodTemplateOverrides merges the user given settings into the pod
+// template built for the policy. Labels used by the DaemonSet selector are
+// never changed and environment variables replace the ones with the same name.
+func applyPodTemplateOverrides(ds *apps.DaemonSet, overrides *networkv1alpha1.PodTemplateOverrides) {
+	if overrides == nil {
+		return
+	}
+
+	template := &ds.Spec.Template
+
+	for k, v := range overrides.Annotations {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[k] = v
+	}
+
+	for k, v := range overrides.Labels {
+		if _, found := ds.Spec.Selector.MatchLabels[k]; found {
+			continue
+		}
+		if template.Labels == nil {
+			template.Labels = map[string]string{}
+		}
+		template.Labels[k] = v
+	}
+
+	if len(overrides.Tolerations) > 0 {
+		template.Spec.Tolerations = slices.Clone(overrides.Tolerations)
+	}
+
+	if len(overrides.PriorityClassName) > 0 {
+		template.Spec.PriorityClassName = overrides.PriorityClassName
+	}
+
+	container := &template.Spec.Containers[0]
+
+	if overrides.Resources != nil {
+		container.Resources = *overrides.Resources.DeepCopy()
+	}
+
+	for _, env := range overrides.Env {
+		idx := slices.IndexFunc(container.Env, func(e v1.EnvVar) bool { return e.Name == env.Name })
+		if idx < 0 {
+			container.Env = append(container.Env, *env.DeepCopy())
+		} else {
+			container.Env[idx] = *env.DeepCopy()
+		}
+	}
+}
+
+// addHealthProbes makes the pod ready only after discover has configured and
+// verified the interfaces, so that the DaemonSet ready count is meaningful.
+func addHealthProbes(container *v1.Container) {
+	container.LivenessProbe = &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{Path: "/livez", Port: intstr.FromString("health")},
+		},
+		PeriodSeconds:    20,
+		FailureThreshold: 3,
+	}
+	container.ReadinessProbe = &v1.Probe{
+		ProbeHandler: v1.ProbeHandler{
+			HTTPGet: &v1.HTTPGetAction{Path: "/readyz", Port: intstr.FromString("health")},
+		},
+		PeriodSeconds: 10,
+	}
+}
+
+func (r *NetworkClusterPolicyReconciler) createGaudiScaleOutDaemonset(netconf client.Object, ctx context.Context, log logr.Logger) (ctrl.Result, error) {
+	cr := netconf.(*networkv1alpha1.NetworkClusterPolicy)
+
+	log.Info("Creating Gaudi Scale-Out DaemonSet", "name", cr.Name)
+
+	ds := r.desiredDaemonSet(netconf)
+	saName := ds.Spec.Template.Spec.ServiceAccountName
 
 	if err := ctrl.SetControllerReference(netconf.(metav1.Object), ds, r.Scheme); err != nil {
 		log.Error(err, "unable to set controller reference")
@@ -227,14 +555,16 @@ func (r *NetworkClusterPolicyReconciler) createGaudiScaleOutDaemonset(netconf cl
 
 	if err := r.Create(ctx, ds); err != nil {
 		log.Error(err, "unable to create DaemonSet")
+		r.recorder.Eventf(cr, v1.EventTypeWarning, reasonDaemonSetFailed, "Unable to create DaemonSet %s: %v", ds.Name, err)
 
 		return ctrl.Result{}, err
 	}
 
 	log.Info("Gaudi scale-out daemonset created")
+	r.recorder.Eventf(cr, v1.EventTypeNormal, reasonDaemonSetCreated, "Created DaemonSet %s/%s", ds.Namespace, ds.Name)
 
 	if saName != "" {
-		r.createOpenShiftCollateral(ctx, log, netconf.(metav1.Object), saName)
+		r.createOpenShiftCollateral(ctx, log, netconf.(metav1.Object), saName, cr.Spec.GaudiScaleOut.ImagePullSecrets)
 	}
 
 	return ctrl.Result{}, nil
@@ -248,27 +578,106 @@ func (r *NetworkClusterPolicyReconciler) createDaemonSet(ctx context.Context, ne
 		return r.createGaudiScaleOutDaemonset(netconf, ctx, log)
 	default:
 		log.Info("Unknown configuration type, this shouldn't happen!", "type", cr.Spec.ConfigurationType)
+		r.recorder.Eventf(cr, v1.EventTypeWarning, reasonInvalidConfiguration,
+			"Unknown configuration type %q", cr.Spec.ConfigurationType)
 
 		return ctrl.Result{}, os.ErrInvalid
 	}
 }
 
-func (r *NetworkClusterPolicyReconciler) updateDaemonSet(ds *apps.DaemonSet, netconf client.Object) {
+// desiredDaemonSet builds the DaemonSet of the policy from scratch, so that
+// the settings removed from the policy are removed from the DaemonSet too.
+func (r *NetworkClusterPolicyReconciler) desiredDaemonSet(netconf client.Object) *apps.DaemonSet {
+	ds := discovery.GaudiDiscoveryDaemonSet()
+
 	cr := netconf.(*networkv1alpha1.NetworkClusterPolicy)
 
+	if r.isOpenShift {
+		ds.Spec.Template.Spec.ServiceAccountName = cr.Name + "-sa"
+	}
+
 	switch cr.Spec.ConfigurationType {
 	case gaudiScaleOutSelection:
 		updateGaudiScaleOutDaemonSet(ds, cr, r.Namespace)
 	default:
 		panic("Unknown configuration type, this shouldn't happen!")
 	}
+
+	return ds
 }
 
-func (r *NetworkClusterPolicyReconciler) updateStatus(rawObj client.Object, ds *apps.DaemonSet, ctx context.Context, log logr.Logger) (ctrl.Result, error) {
+// daemonSetChanged tells whether the DaemonSet differs from the desired one.
+// The desired DaemonSet lacks the defaults set by the API server, so it is
+// compared as a derivative of the existing one. As that ignores the empty
+// fields of the desired DaemonSet, the fields set from the optional parts of
+// the policy are compared exactly for their removal to be noticed.
+func daemonSetChanged(desired, ds *apps.DaemonSet) bool {
+	want, have := &desired.Spec.Template, &ds.Spec.Template
+
+	if !equality.Semantic.DeepDerivative(desired.Spec.UpdateStrategy, ds.Spec.UpdateStrategy) ||
+		!equality.Semantic.DeepDerivative(*want, *have) {
+		return true
+	}
+
+	if !equality.Semantic.DeepEqual(want.Annotations, have.Annotations) ||
+		!equality.Semantic.DeepEqual(want.Labels, have.Labels) ||
+		!equality.Semantic.DeepEqual(want.Spec.NodeSelector, have.Spec.NodeSelector) ||
+		!equality.Semantic.DeepEqual(want.Spec.Tolerations, have.Spec.Tolerations) ||
+		!equality.Semantic.DeepEqual(want.Spec.Affinity, have.Spec.Affinity) ||
+		!equality.Semantic.DeepEqual(want.Spec.ImagePullSecrets, have.Spec.ImagePullSecrets) ||
+		want.Spec.PriorityClassName != have.Spec.PriorityClassName ||
+		want.Spec.ServiceAccountName != have.Spec.ServiceAccountName {
+		return true
+	}
+
+	// the derivative ignores the elements missing from the desired lists
+	if len(want.Spec.Volumes) != len(have.Spec.Volumes) || len(want.Spec.Containers) != len(have.Spec.Containers) {
+		return true
+	}
+
+	for i := range want.Spec.Containers {
+		w, h := &want.Spec.Containers[i], &have.Spec.Containers[i]
+
+		// resources are not defaulted in pod templates, so any difference counts
+		if len(w.Args) != len(h.Args) || len(w.Env) != len(h.Env) || len(w.VolumeMounts) != len(h.VolumeMounts) ||
+			!equality.Semantic.DeepEqual(w.Resources, h.Resources) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keepRestartAnnotation carries the annotation of 'kubectl rollout restart'
+// over to the desired DaemonSet, so that the restart is not undone.
+func keepRestartAnnotation(desired, ds *apps.DaemonSet) {
+	restartedAt, found := ds.Spec.Template.Annotations[restartedAtAnnotation]
+	if !found {
+		return
+	}
+
+	if desired.Spec.Template.Annotations == nil {
+		desired.Spec.Template.Annotations = map[string]string{}
+	}
+
+	desired.Spec.Template.Annotations[restartedAtAnnotation] = restartedAt
+}
+
+func (r *NetworkClusterPolicyReconciler) updateStatus(rawObj client.Object, ds *apps.DaemonSet, rollout *rolloutPlan, problems []string, ctx context.Context, log logr.Logger) (ctrl.Result, error) {
 	nc := rawObj.(*networkv1alpha1.NetworkClusterPolicy)
 
 	updated := false
 
+	if rollout != nil && !equality.Semantic.DeepEqual(nc.Status.Rollout, &rollout.status) {
+		if rollout.status.Phase == rolloutHalted && (nc.Status.Rollout == nil || nc.Status.Rollout.Phase != rolloutHalted) {
+			r.recorder.Eventf(nc, v1.EventTypeWarning, reasonRolloutHalted,
+				"Rollout of revision %d halted: %s", rollout.status.Revision, strings.Join(networkv1alpha1.ErrorMessages(rollout.failures), "; "))
+		}
+
+		nc.Status.Rollout = rollout.status.DeepCopy()
+		updated = true
+	}
+
 	if nc.Status.Targets != ds.Status.DesiredNumberScheduled {
 		nc.Status.Targets = ds.Status.DesiredNumberScheduled
 		updated = true
@@ -279,7 +688,15 @@ func (r *NetworkClusterPolicyReconciler) updateStatus(rawObj client.Object, ds *
 		updated = true
 	}
 
-	nc.Status.Errors = []string{}
+	if duration, done := recordPolicyStatus(nc.Name, ds); done {
+		r.recorder.Eventf(nc, v1.EventTypeNormal, reasonRolloutComplete,
+			"All %d targeted nodes are ready after %s", ds.Status.DesiredNumberScheduled, duration.Round(time.Second))
+	}
+
+	r.reportFailingNodes(ctx, log, nc, ds)
+
+	previousState := nc.Status.State
+	errors := []networkv1beta1.PolicyError{}
 
 	// Update status if there's no State yet.
 	if len(nc.Status.State) == 0 {
@@ -294,8 +711,38 @@ func (r *NetworkClusterPolicyReconciler) updateStatus(rawObj client.Object, ds *
 		nc.Status.State = "All good"
 	}
 
+	contested, err := r.contestedNodes(ctx, nc)
+	if err != nil {
+		log.Error(err, "unable to check for overlapping policies")
+	}
+
+	if len(contested) > 0 {
+		nc.Status.State = stateDegraded
+		errors = append(errors, contestedNodeErrors(contested)...)
+
+		if previousState != stateDegraded {
+			r.recorder.Eventf(nc, v1.EventTypeWarning, reasonPolicyConflict,
+				"%d nodes are targeted by other policies as well", len(contested))
+		}
+	}
+
+	if rollout != nil {
+		errors = append(errors, rollout.failures...)
+	}
+
+	for _, problem := range problems {
+		errors = append(errors, networkv1beta1.PolicyError{Message: problem})
+	}
+
+	// explain the missing targets once the DaemonSet controller has looked for them
+	if nc.Status.Targets == 0 && len(problems) == 0 && ds.Generation > 0 && ds.Status.ObservedGeneration == ds.Generation {
+		errors = append(errors, networkv1beta1.PolicyError{Message: "no nodes match the node selectors of the policy"})
+	}
+
+	updated = updated || previousState != nc.Status.State || !slices.Equal(nc.Status.Errors, networkv1alpha1.ErrorMessages(errors))
+
 	if updated {
-		if err := r.Status().Update(ctx, nc); apierrors.IsConflict(err) {
+		if err := r.writeStatus(ctx, nc, errors); apierrors.IsConflict(err) {
 			return ctrl.Result{Requeue: true}, nil
 		} else if err != nil {
 			log.Error(err, "unable to update network conf status")
@@ -306,6 +753,122 @@ func (r *NetworkClusterPolicyReconciler) updateStatus(rawObj client.Object, ds *
 	return ctrl.Result{}, nil
 }
 
+// writeStatus updates the status of the policy through the v1beta1 hub
+// version, which keeps the nodes of the errors. The policy is refreshed from
+// the written hub.
+func (r *NetworkClusterPolicyReconciler) writeStatus(ctx context.Context, cr *networkv1alpha1.NetworkClusterPolicy, errors []networkv1beta1.PolicyError) error {
+	hub := &networkv1beta1.NetworkClusterPolicy{}
+	if err := cr.ConvertTo(hub); err != nil {
+		return err
+	}
+
+	hub.Status.Errors = errors
+
+	if err := r.Status().Update(ctx, hub); err != nil {
+		return err
+	}
+
+	return cr.ConvertFrom(hub)
+}
+
+// failureTracker remembers the failing nodes of every policy, so that a node
+// failure is reported only when the node starts failing.
+type failureTracker struct {
+	mutex sync.Mutex
+	nodes map[string]map[string]bool
+}
+
+var nodeFailures = &failureTracker{nodes: map[string]map[string]bool{}}
+
+// update records the failing nodes of the policy and returns the ones that
+// were not failing on the previous update.
+func (t *failureTracker) update(policy string, failing []string) []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	previous := t.nodes[policy]
+	current := make(map[string]bool, len(failing))
+	started := []string{}
+
+	for _, node := range failing {
+		current[node] = true
+
+		if !previous[node] {
+			started = append(started, node)
+		}
+	}
+
+	t.nodes[policy] = current
+
+	return started
+}
+
+func (t *failureTracker) forget(policy string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.nodes, policy)
+}
+
+// reportFailingNodes emits an event when the discovery pod of a node starts
+// failing or does not become ready within the grace period.
+func (r *NetworkClusterPolicyReconciler) reportFailingNodes(ctx context.Context, log logr.Logger, nc *networkv1alpha1.NetworkClusterPolicy, ds *apps.DaemonSet) {
+	reasons := map[string]string{}
+
+	if ds.Status.NumberUnavailable > 0 {
+		var pods v1.PodList
+		if err := r.List(ctx, &pods, client.InNamespace(ds.Namespace), client.MatchingFields{ownerKey: ds.Name}); err != nil {
+			log.Error(err, "unable to list discovery pods")
+
+			return
+		}
+
+		now := time.Now()
+
+		for _, pod := range pods.Items {
+			if pod.Spec.NodeName == "" {
+				continue
+			}
+
+			if reason := podFailure(&pod, now); reason != "" {
+				reasons[pod.Spec.NodeName] = reason
+			}
+		}
+	}
+
+	started := nodeFailures.update(nc.Name, slices.Sorted(maps.Keys(reasons)))
+
+	for _, node := range started {
+		r.recorder.Eventf(nc, v1.EventTypeWarning, reasonNodeFailed,
+			"Node %s failed configuration: %s", node, reasons[node])
+	}
+}
+
+// podFailure tells why a discovery pod is considered failed, or returns an
+// empty string if it is ready or still within the grace period.
+func podFailure(pod *v1.Pod, now time.Time) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason == "CrashLoopBackOff" {
+			return fmt.Sprintf("container %s is crash looping", status.Name)
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 && !status.Ready {
+			return fmt.Sprintf("container %s exited with code %d: %s", status.Name, terminated.ExitCode, terminated.Reason)
+		}
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type != v1.PodReady || cond.Status == v1.ConditionTrue {
+			continue
+		}
+
+		if now.Sub(cond.LastTransitionTime.Time) > failingNodeGracePeriod {
+			return fmt.Sprintf("not ready within %s", failingNodeGracePeriod)
+		}
+	}
+
+	return ""
+}
+
 func createEmptyObject() client.Object {
 	return &networkv1alpha1.NetworkClusterPolicy{}
 }
@@ -320,45 +883,133 @@ func (r *NetworkClusterPolicyReconciler) Reconcile(ctx context.Context, req ctrl
 	if err := r.Get(ctx, req.NamespacedName, netConfObj); err != nil {
 		if client.IgnoreNotFound(err) != nil {
 			log.Error(err, "unable to fetch NetworkClusterPolicies")
+			recordReconcile(req.Name, ctrl.Result{}, err, "fetch_failed")
 		}
 
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
+	cr := netConfObj.(*networkv1alpha1.NetworkClusterPolicy)
+
+	if !cr.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, log, cr)
+	}
+
+	if controllerutil.AddFinalizer(cr, policyFinalizer) {
+		if err := r.Update(ctx, cr); err != nil {
+			log.Error(err, "unable to add finalizer")
+			recordReconcile(req.Name, ctrl.Result{}, err, "update_failed")
+
+			return ctrl.Result{}, err
+		}
+	}
+
+	problems := r.syncNodeFeatureRule(ctx, log, cr)
+
 	// fetch possible existing daemonset
 
 	var olderDs apps.DaemonSetList
 	if err := r.List(ctx, &olderDs, client.InNamespace(r.Namespace), client.MatchingFields{ownerKey: req.Name}); err != nil {
 		log.Error(err, "unable to list child DaemonSets")
+		recordReconcile(req.Name, ctrl.Result{}, err, "list_failed")
 
 		return ctrl.Result{}, err
 	}
 
 	if len(olderDs.Items) == 0 {
-		return r.createDaemonSet(ctx, netConfObj, log)
+		res, err := r.createDaemonSet(ctx, netConfObj, log)
+		if err != nil {
+			recordReconcile(req.Name, res, err, "create_failed")
+		} else {
+			recordReconcile(req.Name, res, err, "created")
+			rollouts.start(req.Name)
+		}
+
+		return res, err
 	}
 
 	// Update DaemonSet
 
 	ds := &olderDs.Items[0]
-	originalDs := ds.DeepCopy()
 
-	r.updateDaemonSet(ds, netConfObj)
+	desired := r.desiredDaemonSet(netConfObj)
+	keepRestartAnnotation(desired, ds)
+
+	reason := "in_sync"
 
-	dsDiff := cmp.Diff(originalDs.Spec.Template.Spec, ds.Spec.Template.Spec, diff.IgnoreUnset())
-	if len(dsDiff) > 0 {
-		log.Info("DS difference", "diff", dsDiff)
+	if daemonSetChanged(desired, ds) {
+		log.Info("DS difference", "diff", cmp.Diff(ds.Spec.Template, desired.Spec.Template))
+
+		ds.Spec.Template = desired.Spec.Template
+		ds.Spec.UpdateStrategy = desired.Spec.UpdateStrategy
 
 		if err := r.Update(ctx, ds); err != nil {
 			log.Error(err, "unable to update daemonset", "DaemonSet", ds)
+			recordReconcile(req.Name, ctrl.Result{}, err, "update_failed")
+			r.recorder.Eventf(netConfObj, v1.EventTypeWarning, reasonDaemonSetFailed, "Unable to update DaemonSet %s: %v", ds.Name, err)
 
 			return ctrl.Result{}, err
 		}
+
+		daemonSetUpdates.WithLabelValues(req.Name).Inc()
+		r.recorder.Eventf(netConfObj, v1.EventTypeNormal, reasonDaemonSetUpdated, "Updated DaemonSet %s/%s", ds.Namespace, ds.Name)
+		rollouts.restart(req.Name)
+
+		reason = "daemonset_updated"
+	}
+
+	if r.isOpenShift {
+		if err := r.syncServiceAccountPullSecrets(ctx, log, cr); err != nil {
+			log.Error(err, "unable to update service account pull secrets")
+			r.recorder.Eventf(netConfObj, v1.EventTypeWarning, reasonCollateralFailed,
+				"Unable to update pull secrets of service account %s-sa: %v", cr.Name, err)
+		}
+	}
+
+	rollout, err := r.progressRollout(ctx, log, cr, ds)
+	if err != nil {
+		log.Error(err, "unable to progress the rollout")
+		recordReconcile(req.Name, ctrl.Result{}, err, "rollout_failed")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.recordAgentNodes(ctx, cr, ds); err != nil {
+		log.Error(err, "unable to record the agent nodes")
+		recordReconcile(req.Name, ctrl.Result{}, err, "record_failed")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncReadinessTaints(ctx, log, cr, ds); err != nil {
+		log.Error(err, "unable to update the readiness taints")
+		recordReconcile(req.Name, ctrl.Result{}, err, "taint_failed")
+
+		return ctrl.Result{}, err
+	}
+
+	if err := r.syncNodeConditions(ctx, log, cr, ds); err != nil {
+		log.Error(err, "unable to update the node conditions")
+		recordReconcile(req.Name, ctrl.Result{}, err, "condition_failed")
+
+		return ctrl.Result{}, err
 	}
 
 	// Update Pods Statuses
 
-	return r.updateStatus(netConfObj, ds, ctx, log)
+	res, err := r.updateStatus(netConfObj, ds, rollout, problems, ctx, log)
+	if err != nil {
+		reason = "status_update_failed"
+	}
+
+	// canary nodes failing without the pod changing are only noticed by polling
+	if err == nil && res.IsZero() && rollout != nil && rollout.status.Phase == rolloutProgressing && rollout.status.CanaryNodes > 0 {
+		res.RequeueAfter = rolloutCheckInterval
+	}
+
+	recordReconcile(req.Name, res, err, reason)
+
+	return res, err
 }
 
 func indexDaemonSets(ctx context.Context, mgr ctrl.Manager, apiGVString, pluginKind string) error {
@@ -407,6 +1058,7 @@ func indexPods(ctx context.Context, mgr ctrl.Manager) error {
 func (r *NetworkClusterPolicyReconciler) SetupWithManager(mgr ctrl.Manager, isOpenShift bool) error {
 	r.Scheme = mgr.GetScheme()
 	r.isOpenShift = isOpenShift
+	r.recorder = mgr.GetEventRecorderFor("networkclusterpolicy-controller")
 
 	ctx := context.Background()
 	apiGVString := networkv1alpha1.GroupVersion.String()
@@ -425,5 +1077,10 @@ func (r *NetworkClusterPolicyReconciler) SetupWithManager(mgr ctrl.Manager, isOp
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&networkv1alpha1.NetworkClusterPolicy{}).
 		Owns(&apps.DaemonSet{}).
+		// overlaps between the policies are rechecked on selector and node label changes
+		Watches(&networkv1alpha1.NetworkClusterPolicy{}, handler.EnqueueRequestsFromMapFunc(r.allPolicies),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&v1.Node{}, handler.EnqueueRequestsFromMapFunc(r.allPolicies),
+			builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Complete(r)
 }
@@ -16,18 +16,34 @@ package controller
 
 import (
 	"context"
+	"maps"
 	"time"
 
+	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	networkv1beta1 "github.com/intel/network-operator/api/v1beta1"
+	discovery "github.com/intel/network-operator/config/discovery"
+	"github.com/intel/network-operator/config/nfd"
 )
 
 var _ = Describe("NetworkClusterPolicy Controller", func() {
@@ -97,6 +113,7 @@ var _ = Describe("NetworkClusterPolicy Controller", func() {
 				g.Expect(nicpolicy.Spec.ConfigurationType).To(BeEquivalentTo("gaudi-so"))
 				g.Expect(nicpolicy.Status.Targets).To(BeIdenticalTo(int32(0)))
 				g.Expect(nicpolicy.Status.State).To(BeIdenticalTo("No targets"))
+				g.Expect(nicpolicy.Finalizers).To(ContainElement(policyFinalizer))
 			}, timeout, interval).Should(Succeed())
 
 			var ds apps.DaemonSet
@@ -109,20 +126,26 @@ var _ = Describe("NetworkClusterPolicy Controller", func() {
 				g.Expect(ds.Spec.Template.Spec.ServiceAccountName).To(BeEquivalentTo(resourceName + "-sa"))
 				g.Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Image).To(BeEquivalentTo("intel/my-linkdiscovery:latest"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].Args).To(HaveLen(6))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args).To(HaveLen(8))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[0]).To(BeEquivalentTo("--configure=true"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[1]).To(BeEquivalentTo("--keep-running"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[2]).To(BeEquivalentTo("--mode=L3"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[3]).To(BeEquivalentTo("--mtu=8000"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[4]).To(BeEquivalentTo("--wait=90s"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[5]).To(BeEquivalentTo("--gaudinet=/host/etc/habanalabs/gaudinet.json"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[6]).To(BeEquivalentTo("--metrics-bind-address=:9112"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[7]).To(BeEquivalentTo("--health-probe-bind-address=:9113"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet.Path).To(BeEquivalentTo("/readyz"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].LivenessProbe.HTTPGet.Path).To(BeEquivalentTo("/livez"))
 
-				g.Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(2))
+				g.Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(3))
 				g.Expect(ds.Spec.Template.Spec.Volumes[0].Name).To(BeEquivalentTo("nfd-features"))
-				g.Expect(ds.Spec.Template.Spec.Volumes[1].Name).To(BeEquivalentTo("gaudinetpath"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(2))
+				g.Expect(ds.Spec.Template.Spec.Volumes[1].Name).To(BeEquivalentTo("discover-state"))
+				g.Expect(ds.Spec.Template.Spec.Volumes[2].Name).To(BeEquivalentTo("gaudinetpath"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(3))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[0].Name).To(BeEquivalentTo("nfd-features"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[1].Name).To(BeEquivalentTo("gaudinetpath"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[1].Name).To(BeEquivalentTo("discover-state"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[2].Name).To(BeEquivalentTo("gaudinetpath"))
 
 				// Check for service account and role binding
 				g.Expect(k8sClient.Get(ctx, serviceAccountTypeNamespacedName, &sa)).To(Succeed())
@@ -136,6 +159,9 @@ var _ = Describe("NetworkClusterPolicy Controller", func() {
 			Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
 
 			resource.Spec.GaudiScaleOut.Layer = "L2"
+			resource.Spec.GaudiScaleOut.ApplyPolicy = "strict"
+			minReady := intstr.FromString("90%")
+			resource.Spec.GaudiScaleOut.MinReadyPorts = &minReady
 
 			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
 
@@ -143,17 +169,25 @@ var _ = Describe("NetworkClusterPolicy Controller", func() {
 				g.Expect(k8sClient.Get(ctx, typeNamespacedName, &ds)).To(Succeed())
 				g.Expect(ds.ObjectMeta.Name).To(BeEquivalentTo(typeNamespacedName.Name))
 				g.Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].Args).To(HaveLen(4))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args).To(HaveLen(8))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[0]).To(BeEquivalentTo("--configure=true"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[1]).To(BeEquivalentTo("--keep-running"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[2]).To(BeEquivalentTo("--mode=L2"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[3]).To(BeEquivalentTo("--mtu=8000"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[4]).To(BeEquivalentTo("--apply-policy=strict"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[5]).To(BeEquivalentTo("--min-ready-ports=90%"))
 			}, timeout, interval).Should(Succeed())
 
 			// Test NetworkManager disabling
 			resource.Spec.GaudiScaleOut.Layer = "L3"
 			resource.Spec.GaudiScaleOut.DisableNetworkManager = true
 			resource.Spec.GaudiScaleOut.MTU = 0
+			resource.Spec.GaudiScaleOut.ApplyPolicy = ""
+			resource.Spec.GaudiScaleOut.MinReadyPorts = nil
+			resource.Spec.GaudiScaleOut.Verification = &networkv1alpha1.VerificationSpec{Attempts: 2}
+			resource.Spec.GaudiScaleOut.Persistence = "systemd-networkd"
+			resource.Spec.GaudiScaleOut.PullPolicy = "Always"
+			resource.Spec.GaudiScaleOut.ImagePullSecrets = []core.LocalObjectReference{{Name: "registry-secret"}}
 
 			Expect(k8sClient.Update(ctx, resource)).To(Succeed())
 
@@ -161,22 +195,31 @@ var _ = Describe("NetworkClusterPolicy Controller", func() {
 				g.Expect(k8sClient.Get(ctx, typeNamespacedName, &ds)).To(Succeed())
 				g.Expect(ds.ObjectMeta.Name).To(BeEquivalentTo(typeNamespacedName.Name))
 				g.Expect(ds.Spec.Template.Spec.Containers).To(HaveLen(1))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].Args).To(HaveLen(6))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args).To(HaveLen(11))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[0]).To(BeEquivalentTo("--configure=true"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[1]).To(BeEquivalentTo("--keep-running"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[2]).To(BeEquivalentTo("--mode=L3"))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[3]).To(BeEquivalentTo("--disable-networkmanager"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[6]).To(BeEquivalentTo("--verify"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[7]).To(BeEquivalentTo("--verify-attempts=2"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].Args[8]).To(BeEquivalentTo("--systemd-networkd=/host/etc/systemd/network"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].ImagePullPolicy).To(BeEquivalentTo(core.PullAlways))
+				g.Expect(ds.Spec.Template.Spec.ImagePullSecrets).To(Equal([]core.LocalObjectReference{{Name: "registry-secret"}}))
 
-				g.Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(4))
+				g.Expect(ds.Spec.Template.Spec.Volumes).To(HaveLen(6))
 				g.Expect(ds.Spec.Template.Spec.Volumes[0].Name).To(BeEquivalentTo("nfd-features"))
-				g.Expect(ds.Spec.Template.Spec.Volumes[1].Name).To(BeEquivalentTo("gaudinetpath"))
-				g.Expect(ds.Spec.Template.Spec.Volumes[2].Name).To(BeEquivalentTo("var-run-dbus"))
-				g.Expect(ds.Spec.Template.Spec.Volumes[3].Name).To(BeEquivalentTo("networkmanager"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(4))
+				g.Expect(ds.Spec.Template.Spec.Volumes[1].Name).To(BeEquivalentTo("discover-state"))
+				g.Expect(ds.Spec.Template.Spec.Volumes[2].Name).To(BeEquivalentTo("gaudinetpath"))
+				g.Expect(ds.Spec.Template.Spec.Volumes[3].Name).To(BeEquivalentTo("var-run-dbus"))
+				g.Expect(ds.Spec.Template.Spec.Volumes[4].Name).To(BeEquivalentTo("networkmanager"))
+				g.Expect(ds.Spec.Template.Spec.Volumes[5].Name).To(BeEquivalentTo("networkd"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts).To(HaveLen(6))
 				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[0].Name).To(BeEquivalentTo("nfd-features"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[1].Name).To(BeEquivalentTo("gaudinetpath"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[2].Name).To(BeEquivalentTo("var-run-dbus"))
-				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[3].Name).To(BeEquivalentTo("networkmanager"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[1].Name).To(BeEquivalentTo("discover-state"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[2].Name).To(BeEquivalentTo("gaudinetpath"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[3].Name).To(BeEquivalentTo("var-run-dbus"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[4].Name).To(BeEquivalentTo("networkmanager"))
+				g.Expect(ds.Spec.Template.Spec.Containers[0].VolumeMounts[5].Name).To(BeEquivalentTo("networkd"))
 			}, timeout, interval).Should(Succeed())
 
 			Expect(k8sClient.Delete(ctx, nicpolicy)).To(Succeed())
@@ -191,3 +234,869 @@ var _ = Describe("NetworkClusterPolicy Controller", func() {
 		})
 	})
 })
+
+var _ = Describe("Operator metrics", func() {
+	const policy = "metrics-policy"
+
+	AfterEach(func() {
+		forgetPolicyMetrics(policy)
+	})
+
+	It("should track the rollout of a policy until all the targets are ready", func() {
+		now := time.Unix(1000, 0)
+		rollouts.now = func() time.Time { return now }
+		defer func() { rollouts.now = time.Now }()
+
+		ds := &apps.DaemonSet{}
+		ds.Generation = 2
+		ds.Status = apps.DaemonSetStatus{
+			ObservedGeneration:     2,
+			DesiredNumberScheduled: 3,
+			UpdatedNumberScheduled: 3,
+			NumberReady:            1,
+			NumberUnavailable:      2,
+		}
+
+		recordPolicyStatus(policy, ds)
+
+		Expect(testutil.ToFloat64(policyTargets.WithLabelValues(policy))).To(BeEquivalentTo(3))
+		Expect(testutil.ToFloat64(policyReadyNodes.WithLabelValues(policy))).To(BeEquivalentTo(1))
+		Expect(testutil.ToFloat64(policyFailingNodes.WithLabelValues(policy))).To(BeEquivalentTo(2))
+		Expect(testutil.ToFloat64(rolloutStartTime.WithLabelValues(policy))).To(BeEquivalentTo(1000))
+
+		now = now.Add(time.Minute)
+		ds.Status.NumberReady = 3
+		ds.Status.NumberUnavailable = 0
+
+		recordPolicyStatus(policy, ds)
+
+		Expect(testutil.CollectAndCount(rolloutStartTime)).To(BeZero())
+		Expect(testutil.CollectAndCount(rolloutDuration)).To(Equal(1))
+
+		recordReconcile(policy, ctrl.Result{}, nil, "in_sync")
+		recordReconcile(policy, ctrl.Result{Requeue: true}, nil, "in_sync")
+		Expect(testutil.ToFloat64(reconcileTotal.WithLabelValues(policy, reconcileSuccess, "in_sync"))).To(BeEquivalentTo(1))
+		Expect(testutil.ToFloat64(reconcileTotal.WithLabelValues(policy, reconcileRequeue, "in_sync"))).To(BeEquivalentTo(1))
+
+		forgetPolicyMetrics(policy)
+
+		Expect(testutil.CollectAndCount(policyTargets)).To(BeZero())
+		Expect(testutil.CollectAndCount(reconcileTotal)).To(BeZero())
+	})
+})
+
+var _ = Describe("Discovery pod failures", func() {
+	now := time.Unix(10000, 0)
+
+	It("should not report ready or starting pods", func() {
+		pod := &core.Pod{Status: core.PodStatus{
+			Conditions: []core.PodCondition{{
+				Type:               core.PodReady,
+				Status:             core.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+			}},
+		}}
+		Expect(podFailure(pod, now)).To(BeEmpty())
+
+		pod.Status.Conditions[0].Status = core.ConditionTrue
+		pod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Hour))
+		Expect(podFailure(pod, now)).To(BeEmpty())
+	})
+
+	It("should report pods that stay unready or crash", func() {
+		pod := &core.Pod{Status: core.PodStatus{
+			Conditions: []core.PodCondition{{
+				Type:               core.PodReady,
+				Status:             core.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(now.Add(-10 * time.Minute)),
+			}},
+		}}
+		Expect(podFailure(pod, now)).To(Equal("not ready within 5m0s"))
+
+		pod.Status.ContainerStatuses = []core.ContainerStatus{{
+			Name:  "discover",
+			State: core.ContainerState{Waiting: &core.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		}}
+		Expect(podFailure(pod, now)).To(ContainSubstring("crash looping"))
+	})
+
+	It("should report a failing node only when it starts failing", func() {
+		tracker := &failureTracker{nodes: map[string]map[string]bool{}}
+
+		Expect(tracker.update("policy", []string{"node-a"})).To(Equal([]string{"node-a"}))
+		Expect(tracker.update("policy", []string{"node-a", "node-b"})).To(Equal([]string{"node-b"}))
+		Expect(tracker.update("policy", []string{"node-a", "node-b"})).To(BeEmpty())
+		Expect(tracker.update("other", []string{"node-a"})).To(Equal([]string{"node-a"}))
+
+		// a node failing again after recovering is reported again
+		Expect(tracker.update("policy", []string{"node-b"})).To(BeEmpty())
+		Expect(tracker.update("policy", []string{"node-a", "node-b"})).To(Equal([]string{"node-a"}))
+
+		tracker.forget("policy")
+		Expect(tracker.update("policy", []string{"node-b"})).To(Equal([]string{"node-b"}))
+	})
+})
+
+var _ = Describe("Policy cleanup", func() {
+	It("should name the cleanup jobs uniquely per policy instance and node", func() {
+		cr := &networkv1alpha1.NetworkClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "a-very-long-policy-name-that-goes-on-and-on-and-on", UID: "1234"},
+		}
+
+		name := cleanupJobName(cr, "node-1")
+		Expect(len(name)).To(BeNumerically("<=", 63))
+		Expect(name).To(Equal(cleanupJobName(cr, "node-1")))
+		Expect(name).NotTo(Equal(cleanupJobName(cr, "node-2")))
+
+		cr.UID = "5678"
+		Expect(name).NotTo(Equal(cleanupJobName(cr, "node-1")))
+	})
+
+	It("should clean up the nodes where the node agent has run", func() {
+		cr := &networkv1alpha1.NetworkClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy", UID: "1234"},
+			Spec: networkv1alpha1.NetworkClusterPolicySpec{
+				ConfigurationType: "gaudi-so",
+				NodeSelector:      map[string]string{"gaudi": "true"},
+				GaudiScaleOut:     networkv1alpha1.GaudiScaleOutSpec{Layer: "L3"},
+			},
+		}
+
+		nodes := []client.Object{
+			&core.Node{ObjectMeta: metav1.ObjectMeta{Name: "targeted", Labels: map[string]string{"gaudi": "true"}}},
+			// relabeled out of the policy after the agent ran there
+			&core.Node{ObjectMeta: metav1.ObjectMeta{Name: "relabeled", Annotations: map[string]string{agentNodeAnnotation(cr): "policy"}}},
+			&core.Node{ObjectMeta: metav1.ObjectMeta{Name: "other", Annotations: map[string]string{agentNodeAnnotationPrefix + "5678": "policy"}}},
+		}
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(networkv1alpha1.AddToScheme(scheme)).To(Succeed())
+
+		r := &NetworkClusterPolicyReconciler{
+			Client:    fake.NewClientBuilder().WithScheme(scheme).WithObjects(nodes...).Build(),
+			Scheme:    scheme,
+			Namespace: "default",
+		}
+
+		created, err := r.createCleanupJobs(context.Background(), logr.Discard(), cr, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created).To(Equal(2))
+
+		var jobs batch.JobList
+		Expect(r.List(context.Background(), &jobs)).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(2))
+
+		for _, job := range jobs.Items {
+			Expect(job.Spec.Template.Spec.NodeName).To(BeElementOf("targeted", "relabeled"))
+			Expect(metav1.IsControlledBy(&job, cr)).To(BeTrue())
+		}
+
+		Expect(r.forgetAgentNodes(context.Background(), cr)).To(Succeed())
+
+		var relabeled core.Node
+		Expect(r.Get(context.Background(), types.NamespacedName{Name: "relabeled"}, &relabeled)).To(Succeed())
+		Expect(relabeled.Annotations).NotTo(HaveKey(agentNodeAnnotation(cr)))
+	})
+
+	It("should tell when a cleanup job has finished", func() {
+		job := &batch.Job{}
+
+		finished, failed := jobFinished(job)
+		Expect(finished).To(BeFalse())
+		Expect(failed).To(BeFalse())
+
+		job.Status.Conditions = []batch.JobCondition{{Type: batch.JobComplete, Status: core.ConditionTrue}}
+		finished, failed = jobFinished(job)
+		Expect(finished).To(BeTrue())
+		Expect(failed).To(BeFalse())
+
+		job.Status.Conditions = []batch.JobCondition{{Type: batch.JobFailed, Status: core.ConditionTrue}}
+		finished, failed = jobFinished(job)
+		Expect(finished).To(BeTrue())
+		Expect(failed).To(BeTrue())
+	})
+})
+
+var _ = Describe("Image pull secrets", func() {
+	It("should add the missing pull secrets to the service account", func() {
+		sa := &core.ServiceAccount{
+			ImagePullSecrets: []core.LocalObjectReference{{Name: "foo-sa-dockercfg-abcde"}},
+		}
+
+		secrets := []core.LocalObjectReference{{Name: "registry-secret"}}
+
+		Expect(syncPullSecrets(sa, secrets)).To(BeTrue())
+		Expect(syncPullSecrets(sa, secrets)).To(BeFalse())
+		Expect(sa.ImagePullSecrets).To(Equal([]core.LocalObjectReference{
+			{Name: "foo-sa-dockercfg-abcde"},
+			{Name: "registry-secret"},
+		}))
+	})
+
+	It("should remove only the pull secrets the operator added", func() {
+		sa := &core.ServiceAccount{
+			ImagePullSecrets: []core.LocalObjectReference{{Name: "foo-sa-dockercfg-abcde"}},
+		}
+
+		Expect(syncPullSecrets(sa, []core.LocalObjectReference{{Name: "old-secret"}, {Name: "kept-secret"}})).To(BeTrue())
+		Expect(syncPullSecrets(sa, []core.LocalObjectReference{{Name: "kept-secret"}})).To(BeTrue())
+		Expect(sa.ImagePullSecrets).To(Equal([]core.LocalObjectReference{
+			{Name: "foo-sa-dockercfg-abcde"},
+			{Name: "kept-secret"},
+		}))
+
+		Expect(syncPullSecrets(sa, nil)).To(BeTrue())
+		Expect(sa.ImagePullSecrets).To(Equal([]core.LocalObjectReference{{Name: "foo-sa-dockercfg-abcde"}}))
+		Expect(sa.Annotations).NotTo(HaveKey(pullSecretsAnnotation))
+		Expect(syncPullSecrets(sa, nil)).To(BeFalse())
+	})
+
+	It("should always set the pull secrets of the policy to the DaemonSet", func() {
+		cr := &networkv1alpha1.NetworkClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "secrets"},
+			Spec: networkv1alpha1.NetworkClusterPolicySpec{
+				ConfigurationType: "gaudi-so",
+				GaudiScaleOut: networkv1alpha1.GaudiScaleOutSpec{
+					Layer:            "L2",
+					ImagePullSecrets: []core.LocalObjectReference{{Name: "registry-secret"}},
+				},
+			},
+		}
+
+		ds := discovery.GaudiDiscoveryDaemonSet()
+		updateGaudiScaleOutDaemonSet(ds, cr, "default")
+		Expect(ds.Spec.Template.Spec.ImagePullSecrets).To(HaveLen(1))
+
+		cr.Spec.GaudiScaleOut.ImagePullSecrets = nil
+		updateGaudiScaleOutDaemonSet(ds, cr, "default")
+		Expect(ds.Spec.Template.Spec.ImagePullSecrets).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Pod template overrides", func() {
+	It("should merge the overrides into the DaemonSet pod template", func() {
+		ds := discovery.GaudiDiscoveryDaemonSet()
+		cr := &networkv1alpha1.NetworkClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "overrides"},
+			Spec: networkv1alpha1.NetworkClusterPolicySpec{
+				ConfigurationType: "gaudi-so",
+				GaudiScaleOut:     networkv1alpha1.GaudiScaleOutSpec{Layer: "L2"},
+				PodTemplate: &networkv1alpha1.PodTemplateOverrides{
+					Annotations: map[string]string{"example.com/note": "scale-out"},
+					Labels:      map[string]string{"team": "ai", "app": "other"},
+					Tolerations: []core.Toleration{{
+						Key:      "habana.ai/gaudi",
+						Operator: core.TolerationOpExists,
+						Effect:   core.TaintEffectNoSchedule,
+					}},
+					Resources: &core.ResourceRequirements{
+						Limits: core.ResourceList{core.ResourceMemory: resource.MustParse("200Mi")},
+					},
+					PriorityClassName: "system-node-critical",
+					Env: []core.EnvVar{
+						{Name: "HTTP_PROXY", Value: "http://proxy:3128"},
+					},
+				},
+			},
+		}
+
+		updateGaudiScaleOutDaemonSet(ds, cr, "default")
+		// reconciling again must not duplicate anything
+		updateGaudiScaleOutDaemonSet(ds, cr, "default")
+
+		template := ds.Spec.Template
+		Expect(template.Annotations).To(HaveKeyWithValue("example.com/note", "scale-out"))
+		Expect(template.Labels).To(HaveKeyWithValue("team", "ai"))
+		Expect(template.Labels).To(HaveKeyWithValue("app", "intel-network-tools"))
+		Expect(template.Spec.Tolerations).To(HaveLen(1))
+		Expect(template.Spec.PriorityClassName).To(Equal("system-node-critical"))
+		Expect(template.Spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("200Mi"))
+		Expect(template.Spec.Containers[0].Resources.Requests).To(BeEmpty())
+		Expect(template.Spec.Containers[0].Env).To(HaveLen(2))
+		Expect(template.Spec.Containers[0].Env[0].Name).To(Equal("NODE_NAME"))
+		Expect(template.Spec.Containers[0].Env[1].Value).To(Equal("http://proxy:3128"))
+	})
+})
+
+var _ = Describe("DaemonSet reconcile", func() {
+	r := &NetworkClusterPolicyReconciler{Namespace: "default"}
+
+	policy := func() *networkv1alpha1.NetworkClusterPolicy {
+		return &networkv1alpha1.NetworkClusterPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "reconcile"},
+			Spec: networkv1alpha1.NetworkClusterPolicySpec{
+				ConfigurationType: "gaudi-so",
+				GaudiScaleOut:     networkv1alpha1.GaudiScaleOutSpec{Layer: "L3"},
+			},
+		}
+	}
+
+	// existing returns the DaemonSet as the API server stores it, with some
+	// of the defaults added
+	existing := func(cr *networkv1alpha1.NetworkClusterPolicy) *apps.DaemonSet {
+		ds := r.desiredDaemonSet(cr)
+		ds.Spec.Template.Spec.DNSPolicy = core.DNSClusterFirst
+		ds.Spec.Template.Spec.SchedulerName = core.DefaultSchedulerName
+		ds.Spec.Template.Spec.Containers[0].TerminationMessagePath = core.TerminationMessagePathDefault
+		revisions := int32(10)
+		ds.Spec.RevisionHistoryLimit = &revisions
+
+		return ds
+	}
+
+	It("should not update an unchanged DaemonSet", func() {
+		cr := policy()
+		Expect(daemonSetChanged(r.desiredDaemonSet(cr), existing(cr))).To(BeFalse())
+	})
+
+	It("should remove the pod template overrides removed from the policy", func() {
+		cr := policy()
+		cr.Spec.PodTemplate = &networkv1alpha1.PodTemplateOverrides{
+			Annotations: map[string]string{"example.com/note": "scale-out"},
+			Labels:      map[string]string{"team": "ai"},
+			Tolerations: []core.Toleration{{Key: "habana.ai/gaudi", Operator: core.TolerationOpExists}},
+			Resources: &core.ResourceRequirements{
+				Limits: core.ResourceList{core.ResourceMemory: resource.MustParse("200Mi")},
+			},
+			PriorityClassName: "system-node-critical",
+			Env:               []core.EnvVar{{Name: "HTTP_PROXY", Value: "http://proxy:3128"}},
+			Affinity: &core.Affinity{NodeAffinity: &core.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &core.NodeSelector{
+					NodeSelectorTerms: []core.NodeSelectorTerm{{
+						MatchExpressions: []core.NodeSelectorRequirement{{Key: "a", Operator: core.NodeSelectorOpExists}},
+					}},
+				},
+			}},
+		}
+
+		ds := existing(cr)
+		Expect(daemonSetChanged(r.desiredDaemonSet(cr), ds)).To(BeFalse())
+
+		for name, remove := range map[string]func(*networkv1alpha1.PodTemplateOverrides){
+			"annotations":       func(o *networkv1alpha1.PodTemplateOverrides) { o.Annotations = nil },
+			"labels":            func(o *networkv1alpha1.PodTemplateOverrides) { o.Labels = nil },
+			"tolerations":       func(o *networkv1alpha1.PodTemplateOverrides) { o.Tolerations = nil },
+			"resources":         func(o *networkv1alpha1.PodTemplateOverrides) { o.Resources = nil },
+			"priorityClassName": func(o *networkv1alpha1.PodTemplateOverrides) { o.PriorityClassName = "" },
+			"env":               func(o *networkv1alpha1.PodTemplateOverrides) { o.Env = nil },
+			"affinity":          func(o *networkv1alpha1.PodTemplateOverrides) { o.Affinity = nil },
+		} {
+			removed := cr.DeepCopy()
+			remove(removed.Spec.PodTemplate)
+
+			Expect(daemonSetChanged(r.desiredDaemonSet(removed), ds)).To(BeTrue(), name)
+		}
+
+		// the settings outside of the overrides are removed as well
+		verified := cr.DeepCopy()
+		verified.Spec.GaudiScaleOut.Verification = &networkv1alpha1.VerificationSpec{Attempts: 3}
+		Expect(daemonSetChanged(r.desiredDaemonSet(cr), existing(verified))).To(BeTrue())
+
+		removed := cr.DeepCopy()
+		removed.Spec.PodTemplate = nil
+
+		desired := r.desiredDaemonSet(removed)
+		Expect(daemonSetChanged(desired, ds)).To(BeTrue())
+
+		template := desired.Spec.Template
+		Expect(template.Annotations).To(BeEmpty())
+		Expect(template.Labels).NotTo(HaveKey("team"))
+		Expect(template.Spec.Tolerations).To(BeEmpty())
+		Expect(template.Spec.PriorityClassName).To(BeEmpty())
+		Expect(template.Spec.Affinity).To(BeNil())
+		Expect(template.Spec.Containers[0].Env).To(HaveLen(1))
+		Expect(template.Spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("90Mi"))
+	})
+
+	It("should keep the restart of the DaemonSet", func() {
+		cr := policy()
+		ds := existing(cr)
+		ds.Spec.Template.Annotations = map[string]string{restartedAtAnnotation: "2025-01-01T00:00:00Z"}
+
+		desired := r.desiredDaemonSet(cr)
+		Expect(daemonSetChanged(desired, ds)).To(BeTrue())
+
+		keepRestartAnnotation(desired, ds)
+		Expect(daemonSetChanged(desired, ds)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Node label selector", func() {
+	cr := &networkv1alpha1.NetworkClusterPolicy{
+		Spec: networkv1alpha1.NetworkClusterPolicySpec{
+			NodeSelector: map[string]string{"intel.feature.node.kubernetes.io/gaudi-ready": "true"},
+			NodeLabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"zone": "a"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{{
+					Key:      "rack",
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{"r17", "r18"},
+				}},
+			},
+		},
+	}
+
+	It("should translate the label selector to a required node affinity", func() {
+		affinity := policyAffinity(cr)
+
+		Expect(affinity).NotTo(BeNil())
+		terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(Equal([]core.NodeSelectorTerm{{
+			MatchExpressions: []core.NodeSelectorRequirement{
+				{Key: "zone", Operator: core.NodeSelectorOpIn, Values: []string{"a"}},
+				{Key: "rack", Operator: core.NodeSelectorOpNotIn, Values: []string{"r17", "r18"}},
+			},
+		}}))
+	})
+
+	It("should add the requirements to every term of the affinity overrides", func() {
+		withOverrides := cr.DeepCopy()
+		withOverrides.Spec.PodTemplate = &networkv1alpha1.PodTemplateOverrides{
+			Affinity: &core.Affinity{
+				NodeAffinity: &core.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &core.NodeSelector{
+						NodeSelectorTerms: []core.NodeSelectorTerm{
+							{MatchExpressions: []core.NodeSelectorRequirement{{Key: "a", Operator: core.NodeSelectorOpExists}}},
+							{MatchExpressions: []core.NodeSelectorRequirement{{Key: "b", Operator: core.NodeSelectorOpExists}}},
+						},
+					},
+				},
+			},
+		}
+
+		terms := policyAffinity(withOverrides).NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(2))
+		Expect(terms[0].MatchExpressions).To(HaveLen(3))
+		Expect(terms[1].MatchExpressions).To(HaveLen(3))
+
+		// the overrides in the policy are not modified
+		Expect(withOverrides.Spec.PodTemplate.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(HaveLen(1))
+	})
+
+	It("should rebuild the readiness toleration from the policy", func() {
+		r := &NetworkClusterPolicyReconciler{Namespace: "default"}
+
+		tainted := cr.DeepCopy()
+		tainted.Spec.ConfigurationType = "gaudi-so"
+		tainted.Spec.GaudiScaleOut.Layer = "L3"
+		tainted.Spec.ReadinessTaint = &networkv1alpha1.ReadinessTaintSpec{}
+
+		ds := r.desiredDaemonSet(tainted)
+		Expect(ds.Spec.Template.Spec.Tolerations).To(ContainElement(HaveField("Key", defaultReadinessTaintKey)))
+
+		renamed := tainted.DeepCopy()
+		renamed.Spec.ReadinessTaint.Key = "example.com/not-ready"
+
+		desired := r.desiredDaemonSet(renamed)
+		Expect(desired.Spec.Template.Spec.Tolerations).To(ConsistOf(HaveField("Key", "example.com/not-ready")))
+		Expect(daemonSetChanged(desired, ds)).To(BeTrue())
+
+		disabled := tainted.DeepCopy()
+		disabled.Spec.ReadinessTaint = nil
+
+		desired = r.desiredDaemonSet(disabled)
+		Expect(desired.Spec.Template.Spec.Tolerations).To(BeEmpty())
+		Expect(daemonSetChanged(desired, ds)).To(BeTrue())
+	})
+
+	It("should remove the node affinity with the label selector", func() {
+		r := &NetworkClusterPolicyReconciler{Namespace: "default"}
+
+		selected := cr.DeepCopy()
+		selected.Spec.ConfigurationType = "gaudi-so"
+		selected.Spec.GaudiScaleOut.Layer = "L2"
+
+		ds := r.desiredDaemonSet(selected)
+		Expect(ds.Spec.Template.Spec.Affinity).NotTo(BeNil())
+
+		unselected := selected.DeepCopy()
+		unselected.Spec.NodeLabelSelector = nil
+
+		desired := r.desiredDaemonSet(unselected)
+		Expect(desired.Spec.Template.Spec.Affinity).To(BeNil())
+		Expect(daemonSetChanged(desired, ds)).To(BeTrue())
+	})
+
+	It("should select the target nodes with both selectors", func() {
+		selector, err := cr.Spec.TargetNodeSelector()
+		Expect(err).NotTo(HaveOccurred())
+
+		node := labels.Set{"intel.feature.node.kubernetes.io/gaudi-ready": "true", "zone": "a", "rack": "r1"}
+		Expect(selector.Matches(node)).To(BeTrue())
+
+		node["rack"] = "r17"
+		Expect(selector.Matches(node)).To(BeFalse())
+
+		node["rack"] = "r1"
+		node["intel.feature.node.kubernetes.io/gaudi-ready"] = "false"
+		Expect(selector.Matches(node)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Policy overlap", func() {
+	It("should list the contested nodes in a stable order", func() {
+		errors := contestedNodeErrors(map[string][]string{
+			"node-2": {"other"},
+			"node-1": {"a", "b"},
+		})
+
+		Expect(errors).To(Equal([]networkv1beta1.PolicyError{
+			{Node: "node-1", Message: "node node-1 is also targeted by a, b"},
+			{Node: "node-2", Message: "node node-2 is also targeted by other"},
+		}))
+	})
+})
+
+var _ = Describe("Rollout", func() {
+	now := time.Unix(10000, 0)
+
+	hashes := map[string]int64{"old": 1, "new": 2}
+
+	pod := func(node, hash string, ready bool, since time.Duration) core.Pod {
+		status := core.ConditionFalse
+		if ready {
+			status = core.ConditionTrue
+		}
+
+		return core.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "discover-" + node,
+				Labels: map[string]string{apps.DefaultDaemonSetUniqueLabelKey: hash},
+			},
+			Spec: core.PodSpec{NodeName: node},
+			Status: core.PodStatus{Conditions: []core.PodCondition{{
+				Type:               core.PodReady,
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(now.Add(-since)),
+			}}},
+		}
+	}
+
+	replaced := func(plan rolloutPlan) []string {
+		nodes := []string{}
+		for _, pod := range plan.replace {
+			nodes = append(nodes, pod.Spec.NodeName)
+		}
+
+		return nodes
+	}
+
+	It("should leave the rollouts without canary nodes to the DaemonSet controller", func() {
+		strategy := updateStrategy(nil)
+		Expect(strategy.Type).To(Equal(apps.RollingUpdateDaemonSetStrategyType))
+		Expect(strategy.RollingUpdate.MaxUnavailable.IntValue()).To(Equal(1))
+
+		maxUnavailable := intstr.FromString("20%")
+		strategy = updateStrategy(&networkv1alpha1.RolloutSpec{MaxUnavailable: &maxUnavailable})
+		Expect(strategy.RollingUpdate.MaxUnavailable.String()).To(Equal("20%"))
+
+		Expect(updateStrategy(&networkv1alpha1.RolloutSpec{Paused: true}).Type).To(Equal(apps.OnDeleteDaemonSetStrategyType))
+		Expect(updateStrategy(&networkv1alpha1.RolloutSpec{Canary: &networkv1alpha1.CanarySpec{Percentage: 10}}).Type).
+			To(Equal(apps.OnDeleteDaemonSetStrategyType))
+
+		pods := []core.Pod{pod("node-1", "new", true, time.Hour), pod("node-2", "old", true, time.Hour)}
+
+		plan := planRollout(nil, hashes, 2, pods, nil, now)
+		Expect(plan.status.Phase).To(Equal(rolloutProgressing))
+		Expect(plan.status.Revisions).To(Equal([]networkv1alpha1.RevisionStatus{
+			{Revision: 2, Nodes: 1, ReadyNodes: 1},
+			{Revision: 1, Nodes: 1, ReadyNodes: 1},
+		}))
+		Expect(plan.replace).To(BeEmpty())
+	})
+
+	It("should pick the canary nodes in name order", func() {
+		nodes := []core.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-3", Labels: map[string]string{"rack": "a"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"rack": "b"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "node-2", Labels: map[string]string{"rack": "a"}}},
+		}
+
+		Expect(canaryNodes(&networkv1alpha1.CanarySpec{Percentage: 10}, nodes)).To(Equal(map[string]bool{"node-1": true}))
+		Expect(canaryNodes(&networkv1alpha1.CanarySpec{NodeSelector: map[string]string{"rack": "a"}}, nodes)).
+			To(Equal(map[string]bool{"node-2": true, "node-3": true}))
+		Expect(canaryNodes(&networkv1alpha1.CanarySpec{NodeSelector: map[string]string{"rack": "a"}, Percentage: 50}, nodes)).
+			To(Equal(map[string]bool{"node-2": true}))
+	})
+
+	It("should update the canary nodes first and the rest once they are ready", func() {
+		rollout := &networkv1alpha1.RolloutSpec{Canary: &networkv1alpha1.CanarySpec{Percentage: 30}}
+		canary := map[string]bool{"node-2": true}
+
+		pods := []core.Pod{
+			pod("node-1", "old", true, time.Hour),
+			pod("node-2", "old", true, time.Hour),
+			pod("node-3", "old", true, time.Hour),
+		}
+
+		plan := planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(plan.status.Phase).To(Equal(rolloutProgressing))
+		Expect(plan.status.CanaryNodes).To(BeEquivalentTo(1))
+		Expect(replaced(plan)).To(Equal([]string{"node-2"}))
+
+		pods[1] = pod("node-2", "new", false, time.Minute)
+
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(plan.status.Phase).To(Equal(rolloutProgressing))
+		Expect(plan.replace).To(BeEmpty())
+
+		pods[1] = pod("node-2", "new", true, 0)
+
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(replaced(plan)).To(Equal([]string{"node-1"}))
+
+		maxUnavailable := intstr.FromInt32(2)
+		rollout.MaxUnavailable = &maxUnavailable
+
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(replaced(plan)).To(Equal([]string{"node-1", "node-3"}))
+
+		rollout.Paused = true
+
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(plan.status.Phase).To(Equal(rolloutPaused))
+		Expect(plan.replace).To(BeEmpty())
+	})
+
+	It("should halt when a canary node fails", func() {
+		rollout := &networkv1alpha1.RolloutSpec{Canary: &networkv1alpha1.CanarySpec{Percentage: 30}}
+		canary := map[string]bool{"node-1": true}
+
+		pods := []core.Pod{
+			pod("node-1", "new", false, 10*time.Minute),
+			pod("node-2", "old", true, time.Hour),
+		}
+
+		plan := planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(plan.status.Phase).To(Equal(rolloutHalted))
+		Expect(plan.failures).To(ConsistOf(And(
+			HaveField("Node", "node-1"),
+			HaveField("Message", ContainSubstring("canary node node-1 failed")))))
+		Expect(plan.replace).To(BeEmpty())
+
+		pods[0] = pod("node-1", "new", true, 0)
+		pods[1] = pod("node-2", "new", true, 0)
+
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(plan.status.Phase).To(Equal(rolloutComplete))
+		Expect(plan.failures).To(BeEmpty())
+	})
+
+	It("should stay halted when the failed canary node recovers", func() {
+		rollout := &networkv1alpha1.RolloutSpec{Canary: &networkv1alpha1.CanarySpec{Percentage: 30}}
+		canary := map[string]bool{"node-1": true}
+
+		pods := []core.Pod{
+			pod("node-1", "new", false, 10*time.Minute),
+			pod("node-2", "old", true, time.Hour),
+		}
+
+		plan := planRollout(rollout, hashes, 2, pods, canary, now)
+		keepHalted(&plan, nil, 5)
+		Expect(plan.status.Phase).To(Equal(rolloutHalted))
+		Expect(plan.status.HaltedGeneration).To(BeEquivalentTo(5))
+
+		halted := plan.status.DeepCopy()
+
+		// the canary pod restarts and gets ready
+		pods[0] = pod("node-1", "new", true, 0)
+
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		Expect(replaced(plan)).To(Equal([]string{"node-2"}))
+
+		keepHalted(&plan, halted, 5)
+		Expect(plan.status.Phase).To(Equal(rolloutHalted))
+		Expect(plan.replace).To(BeEmpty())
+		Expect(plan.failures).To(ConsistOf(HaveField("Message", ContainSubstring("revision 2 halted"))))
+
+		// a policy update resumes the rollout
+		plan = planRollout(rollout, hashes, 2, pods, canary, now)
+		keepHalted(&plan, halted, 6)
+		Expect(plan.status.Phase).To(Equal(rolloutProgressing))
+		Expect(replaced(plan)).To(Equal([]string{"node-2"}))
+
+		// and so does a new revision
+		plan = planRollout(rollout, map[string]int64{"old": 1, "new": 3}, 3, pods, canary, now)
+		keepHalted(&plan, halted, 5)
+		Expect(plan.status.Phase).To(Equal(rolloutProgressing))
+	})
+})
+
+var _ = Describe("Readiness taint", func() {
+	now := time.Unix(10000, 0)
+
+	It("should let the node agent tolerate the taint once", func() {
+		ds := discovery.GaudiDiscoveryDaemonSet()
+
+		addReadinessToleration(ds, defaultReadinessTaintKey)
+		addReadinessToleration(ds, defaultReadinessTaintKey)
+
+		Expect(ds.Spec.Template.Spec.Tolerations).To(Equal([]core.Toleration{{
+			Key:      defaultReadinessTaintKey,
+			Operator: core.TolerationOpExists,
+			Effect:   core.TaintEffectNoSchedule,
+		}}))
+	})
+
+	It("should add, keep and remove only the taints of the policy", func() {
+		other := core.Taint{Key: "example.com/other", Value: "other", Effect: core.TaintEffectNoSchedule}
+		node := &core.Node{Spec: core.NodeSpec{Taints: []core.Taint{other}}}
+
+		keys := []string{defaultReadinessTaintKey}
+
+		taints, changed := readinessTaints(node, "policy", keys, defaultReadinessTaintKey, true, now)
+		Expect(changed).To(BeTrue())
+		Expect(taints).To(HaveLen(2))
+		Expect(taints[1].Key).To(Equal(defaultReadinessTaintKey))
+		Expect(taints[1].Value).To(Equal("policy"))
+
+		node.Spec.Taints = taints
+
+		_, changed = readinessTaints(node, "policy", keys, defaultReadinessTaintKey, true, now)
+		Expect(changed).To(BeFalse())
+
+		// the earlier key is still recorded while the key changes
+		keys = append(keys, "example.com/not-ready")
+
+		taints, changed = readinessTaints(node, "policy", keys, "example.com/not-ready", true, now)
+		Expect(changed).To(BeTrue())
+		Expect(taints).To(HaveLen(2))
+		Expect(taints[1].Key).To(Equal("example.com/not-ready"))
+
+		node.Spec.Taints = taints
+
+		taints, changed = readinessTaints(node, "policy", keys, defaultReadinessTaintKey, false, now)
+		Expect(changed).To(BeTrue())
+		Expect(taints).To(Equal([]core.Taint{other}))
+	})
+
+	It("should leave the taints of others with the policy name as the value", func() {
+		foreign := core.Taint{Key: "habana.ai/gaudi", Value: "gaudi", Effect: core.TaintEffectNoSchedule}
+		node := &core.Node{Spec: core.NodeSpec{Taints: []core.Taint{foreign}}}
+
+		taints, changed := readinessTaints(node, "gaudi", []string{defaultReadinessTaintKey}, defaultReadinessTaintKey, false, now)
+		Expect(changed).To(BeFalse())
+		Expect(taints).To(Equal([]core.Taint{foreign}))
+
+		taints, changed = readinessTaints(node, "gaudi", []string{defaultReadinessTaintKey}, defaultReadinessTaintKey, true, now)
+		Expect(changed).To(BeTrue())
+		Expect(taints).To(HaveLen(2))
+		Expect(taints[0]).To(Equal(foreign))
+	})
+})
+
+var _ = Describe("Node condition", func() {
+	now := time.Unix(10000, 0)
+
+	It("should describe the scale-out state of the node", func() {
+		cond := scaleOutCondition("policy", nil, now)
+		Expect(cond.Status).To(Equal(core.ConditionFalse))
+		Expect(cond.Reason).To(Equal(conditionReasonAgentNotRunning))
+
+		pod := &core.Pod{Status: core.PodStatus{Conditions: []core.PodCondition{{
+			Type:               core.PodReady,
+			Status:             core.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+		}}}}
+
+		Expect(scaleOutCondition("policy", pod, now).Reason).To(Equal(conditionReasonNotReady))
+
+		pod.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Hour))
+
+		cond = scaleOutCondition("policy", pod, now)
+		Expect(cond.Reason).To(Equal(conditionReasonFailed))
+		Expect(cond.Message).To(ContainSubstring("not ready within 5m0s"))
+
+		pod.Status.Conditions[0].Status = core.ConditionTrue
+
+		cond = scaleOutCondition("policy", pod, now)
+		Expect(cond.Status).To(Equal(core.ConditionTrue))
+		Expect(cond.Message).To(ContainSubstring("policy policy"))
+	})
+
+	It("should keep the transition time until the status changes", func() {
+		node := &core.Node{Status: core.NodeStatus{Conditions: []core.NodeCondition{{Type: core.NodeReady, Status: core.ConditionTrue}}}}
+
+		Expect(setNodeCondition(node, scaleOutCondition("policy", nil, now), now)).To(BeTrue())
+		Expect(node.Status.Conditions).To(HaveLen(2))
+		Expect(setNodeCondition(node, scaleOutCondition("policy", nil, now), now.Add(time.Minute))).To(BeFalse())
+
+		pod := &core.Pod{Status: core.PodStatus{Conditions: []core.PodCondition{{
+			Type:               core.PodReady,
+			Status:             core.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(now),
+		}}}}
+
+		Expect(setNodeCondition(node, scaleOutCondition("policy", pod, now), now.Add(time.Minute))).To(BeTrue())
+		Expect(node.Status.Conditions[1].LastTransitionTime.Time).To(Equal(now))
+		Expect(node.Status.Conditions[1].LastHeartbeatTime.Time).To(Equal(now.Add(time.Minute)))
+
+		pod.Status.Conditions[0].Status = core.ConditionTrue
+
+		Expect(setNodeCondition(node, scaleOutCondition("policy", pod, now), now.Add(2*time.Minute))).To(BeTrue())
+		Expect(node.Status.Conditions[1].Status).To(Equal(core.ConditionTrue))
+		Expect(node.Status.Conditions[1].LastTransitionTime.Time).To(Equal(now.Add(2 * time.Minute)))
+
+		Expect(removeNodeCondition(node)).To(BeTrue())
+		Expect(node.Status.Conditions).To(HaveLen(1))
+		Expect(removeNodeCondition(node)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Node feature rule", func() {
+	matchValues := func(rule *unstructured.Unstructured, feature, key string) [][]string {
+		rules, _, err := unstructured.NestedSlice(rule.Object, "spec", "rules")
+		Expect(err).NotTo(HaveOccurred())
+
+		values := [][]string{}
+		for _, r := range rules {
+			features, _, _ := unstructured.NestedSlice(r.(map[string]interface{}), "matchFeatures")
+			for _, f := range features {
+				if f.(map[string]interface{})["feature"] != feature {
+					continue
+				}
+
+				value, _, _ := unstructured.NestedStringSlice(f.(map[string]interface{}), "matchExpressions", key, "value")
+				values = append(values, value)
+			}
+		}
+
+		return values
+	}
+
+	It("should extend the device IDs and replace the labels of the default rule", func() {
+		cr := &networkv1alpha1.NetworkClusterPolicy{}
+		cr.Name = "gaudi"
+		cr.Spec.GaudiScaleOut.NodeFeatureRule = &networkv1alpha1.NodeFeatureRuleSpec{
+			DeviceIDs:  []string{"1070", "1020"},
+			ReadyLabel: "example.com/gaudi-ready",
+		}
+
+		rule, err := gaudiDeviceRule(cr, []string{"1080"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rule.GetName()).To(Equal("gaudi-gaudi-device-rule"))
+
+		devices := matchValues(rule, "pci.device", "device")
+		Expect(devices).To(HaveLen(2))
+		for _, ids := range devices {
+			Expect(ids).To(Equal([]string{"1020", "1030", "1060", "1080", "1070"}))
+		}
+
+		rules, _, _ := unstructured.NestedSlice(rule.Object, "spec", "rules")
+
+		ruleLabels := map[string]string{}
+		for _, r := range rules {
+			l, _, _ := unstructured.NestedStringMap(r.(map[string]interface{}), "labels")
+			maps.Copy(ruleLabels, l)
+		}
+
+		Expect(ruleLabels).To(Equal(map[string]string{
+			"intel.feature.node.kubernetes.io/gaudi": "true",
+			"example.com/gaudi-ready":                "true",
+		}))
+
+		// the embedded rule is not modified
+		Expect(matchValues(nfd.GaudiDeviceRule(), "pci.device", "device")[0]).To(Equal([]string{"1020", "1030", "1060"}))
+	})
+})
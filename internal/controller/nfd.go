@@ -0,0 +1,177 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/go-logr/logr"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	"github.com/intel/network-operator/config/nfd"
+)
+
+//+kubebuilder:rbac:groups=nfd.k8s-sigs.io,resources=nodefeaturerules,verbs=get;create;update
+
+const (
+	reasonNodeFeatureRuleCreated = "NodeFeatureRuleCreated"
+	reasonNodeFeatureRuleFailed  = "NodeFeatureRuleFailed"
+
+	// explains why a policy relying on the NFD labels has no targets
+	nfdMissing = "NodeFeatureRule API not found, Node Feature Discovery is not installed"
+)
+
+func nodeFeatureRuleName(cr *networkv1alpha1.NetworkClusterPolicy) string {
+	return cr.Name + "-gaudi-device-rule"
+}
+
+// gaudiDeviceRule returns the Gaudi device rule of the policy, with the
+// device IDs of the operator and the policy added to the rule and the labels
+// of the policy replacing the default ones.
+func gaudiDeviceRule(cr *networkv1alpha1.NetworkClusterPolicy, deviceIDs []string) (*unstructured.Unstructured, error) {
+	rule := nfd.GaudiDeviceRule()
+	rule.SetName(nodeFeatureRuleName(cr))
+
+	renames := map[string]string{}
+	deviceIDs = slices.Clone(deviceIDs)
+
+	if spec := cr.Spec.GaudiScaleOut.NodeFeatureRule; spec != nil {
+		deviceIDs = append(deviceIDs, spec.DeviceIDs...)
+
+		if spec.DeviceLabel != "" {
+			renames[nfd.GaudiDeviceLabel] = spec.DeviceLabel
+		}
+		if spec.ReadyLabel != "" {
+			renames[nfd.GaudiReadyLabel] = spec.ReadyLabel
+		}
+	}
+
+	rules, _, err := unstructured.NestedSlice(rule.Object, "spec", "rules")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rules {
+		r, ok := r.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected rule %v", r)
+		}
+
+		if ruleLabels, ok := r["labels"].(map[string]interface{}); ok {
+			for from, to := range renames {
+				if value, found := ruleLabels[from]; found {
+					delete(ruleLabels, from)
+					ruleLabels[to] = value
+				}
+			}
+		}
+
+		features, _ := r["matchFeatures"].([]interface{})
+		for _, f := range features {
+			f, ok := f.(map[string]interface{})
+			if !ok || f["feature"] != "pci.device" {
+				continue
+			}
+
+			devices, _, err := unstructured.NestedStringSlice(f, "matchExpressions", "device", "value")
+			if err != nil {
+				return nil, err
+			}
+
+			for _, id := range deviceIDs {
+				if !slices.Contains(devices, id) {
+					devices = append(devices, id)
+				}
+			}
+
+			if err := unstructured.SetNestedStringSlice(f, devices, "matchExpressions", "device", "value"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := unstructured.SetNestedSlice(rule.Object, rules, "spec", "rules"); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// syncNodeFeatureRule creates or updates the Gaudi device rule of the policy.
+// The problems found are returned for the status of the policy, the rule is
+// deleted together with the policy.
+func (r *NetworkClusterPolicyReconciler) syncNodeFeatureRule(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy) []string {
+	if !r.ManageNodeFeatureRules || cr.Spec.ConfigurationType != gaudiScaleOutSelection {
+		return nil
+	}
+
+	rule, err := gaudiDeviceRule(cr, r.GaudiDeviceIDs)
+	if err != nil {
+		log.Error(err, "invalid Gaudi device rule")
+
+		return []string{fmt.Sprintf("invalid NodeFeatureRule: %v", err)}
+	}
+
+	if err := ctrl.SetControllerReference(cr, rule, r.Scheme); err != nil {
+		return []string{fmt.Sprintf("invalid NodeFeatureRule: %v", err)}
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(rule.GroupVersionKind())
+
+	err = r.Get(ctx, types.NamespacedName{Name: rule.GetName()}, existing)
+
+	switch {
+	case meta.IsNoMatchError(err):
+		return []string{nfdMissing}
+	case apierrors.IsNotFound(err):
+		if err := r.Create(ctx, rule); err != nil {
+			log.Error(err, "unable to create NodeFeatureRule")
+			r.recorder.Eventf(cr, v1.EventTypeWarning, reasonNodeFeatureRuleFailed,
+				"Unable to create NodeFeatureRule %s: %v", rule.GetName(), err)
+
+			return []string{fmt.Sprintf("NodeFeatureRule %s is missing: %v", rule.GetName(), err)}
+		}
+
+		r.recorder.Eventf(cr, v1.EventTypeNormal, reasonNodeFeatureRuleCreated, "Created NodeFeatureRule %s", rule.GetName())
+	case err != nil:
+		log.Error(err, "unable to fetch NodeFeatureRule")
+
+		return []string{fmt.Sprintf("unable to check NodeFeatureRule %s: %v", rule.GetName(), err)}
+	case !equality.Semantic.DeepEqual(existing.Object["spec"], rule.Object["spec"]):
+		existing.Object["spec"] = rule.Object["spec"]
+
+		if err := r.Update(ctx, existing); err != nil {
+			log.Error(err, "unable to update NodeFeatureRule")
+			r.recorder.Eventf(cr, v1.EventTypeWarning, reasonNodeFeatureRuleFailed,
+				"Unable to update NodeFeatureRule %s: %v", rule.GetName(), err)
+
+			return []string{fmt.Sprintf("NodeFeatureRule %s is outdated: %v", rule.GetName(), err)}
+		}
+
+		log.Info("Updated NodeFeatureRule", "name", rule.GetName())
+	}
+
+	return nil
+}
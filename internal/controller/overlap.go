@@ -0,0 +1,90 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	networkv1beta1 "github.com/intel/network-operator/api/v1beta1"
+)
+
+const (
+	// policies sharing nodes with other policies are in this state
+	stateDegraded = "Degraded"
+
+	reasonPolicyConflict = "PolicyConflict"
+)
+
+// contestedNodes returns the nodes targeted by the policy that other
+// policies target as well, with the names of the other policies.
+func (r *NetworkClusterPolicyReconciler) contestedNodes(ctx context.Context, cr *networkv1alpha1.NetworkClusterPolicy) (map[string][]string, error) {
+	var policies networkv1alpha1.NetworkClusterPolicyList
+	if err := r.List(ctx, &policies); err != nil {
+		return nil, err
+	}
+
+	if len(policies.Items) < 2 {
+		return nil, nil
+	}
+
+	var nodes v1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return nil, err
+	}
+
+	return networkv1alpha1.OverlappingNodes(cr, policies.Items, nodes.Items)
+}
+
+func contestedNodeErrors(contested map[string][]string) []networkv1beta1.PolicyError {
+	errors := make([]networkv1beta1.PolicyError, 0, len(contested))
+
+	for _, node := range slices.Sorted(maps.Keys(contested)) {
+		errors = append(errors, networkv1beta1.PolicyError{
+			Node:    node,
+			Message: fmt.Sprintf("node %s is also targeted by %s", node, strings.Join(contested[node], ", ")),
+		})
+	}
+
+	return errors
+}
+
+// allPolicies requests the reconcile of every policy. Node label and policy
+// selector changes may start or end an overlap between any of them.
+func (r *NetworkClusterPolicyReconciler) allPolicies(ctx context.Context, _ client.Object) []reconcile.Request {
+	var policies networkv1alpha1.NetworkClusterPolicyList
+	if err := r.List(ctx, &policies); err != nil {
+		log.FromContext(ctx).Error(err, "unable to list policies")
+
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(policies.Items))
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: policy.Name}})
+	}
+
+	return requests
+}
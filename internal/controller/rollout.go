@@ -0,0 +1,352 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+	networkv1beta1 "github.com/intel/network-operator/api/v1beta1"
+)
+
+//+kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=delete
+
+const (
+	rolloutProgressing = "Progressing"
+	rolloutPaused      = "Paused"
+	rolloutHalted      = "Halted"
+	rolloutComplete    = "Complete"
+
+	reasonRolloutHalted   = "RolloutHalted"
+	reasonRolloutProgress = "RolloutProgressing"
+
+	// canary rollouts are rechecked this often, as the canary nodes are
+	// considered failed only after a grace period
+	rolloutCheckInterval = time.Minute
+)
+
+// rolloutPlan is the observed state of a rollout and the next step in it.
+type rolloutPlan struct {
+	status networkv1alpha1.RolloutStatus
+
+	// pods to delete so that the DaemonSet controller recreates them from
+	// the current revision
+	replace []*v1.Pod
+
+	// failures of the canary nodes that halt the rollout
+	failures []networkv1beta1.PolicyError
+}
+
+// updateStrategy returns the update strategy of the DaemonSet. Paused and
+// canary rollouts are driven by the operator, which deletes the outdated pods
+// itself, other rollouts are left to the DaemonSet controller.
+func updateStrategy(rollout *networkv1alpha1.RolloutSpec) apps.DaemonSetUpdateStrategy {
+	if rollout != nil && (rollout.Paused || rollout.Canary != nil) {
+		return apps.DaemonSetUpdateStrategy{Type: apps.OnDeleteDaemonSetStrategyType}
+	}
+
+	maxUnavailable := intstr.FromInt32(1)
+	if rollout != nil && rollout.MaxUnavailable != nil {
+		maxUnavailable = *rollout.MaxUnavailable
+	}
+
+	maxSurge := intstr.FromInt32(0)
+
+	return apps.DaemonSetUpdateStrategy{
+		Type: apps.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &apps.RollingUpdateDaemonSet{
+			MaxUnavailable: &maxUnavailable,
+			MaxSurge:       &maxSurge,
+		},
+	}
+}
+
+// daemonSetRevisions returns the revision numbers of the DaemonSet by their
+// hash in the pod labels, and the newest revision the pods are created from.
+func (r *NetworkClusterPolicyReconciler) daemonSetRevisions(ctx context.Context, ds *apps.DaemonSet) (map[string]int64, int64, error) {
+	var revisions apps.ControllerRevisionList
+	if err := r.List(ctx, &revisions, client.InNamespace(ds.Namespace), client.MatchingLabels(ds.Spec.Selector.MatchLabels)); err != nil {
+		return nil, 0, err
+	}
+
+	hashes := map[string]int64{}
+	current := int64(0)
+
+	for _, revision := range revisions.Items {
+		if !metav1.IsControlledBy(&revision, ds) {
+			continue
+		}
+
+		hashes[revision.Labels[apps.DefaultDaemonSetUniqueLabelKey]] = revision.Revision
+		current = max(current, revision.Revision)
+	}
+
+	return hashes, current, nil
+}
+
+// canaryNodes picks the canary nodes among the targeted nodes.
+func canaryNodes(canary *networkv1alpha1.CanarySpec, nodes []v1.Node) map[string]bool {
+	if canary == nil {
+		return nil
+	}
+
+	selector := labels.SelectorFromSet(canary.NodeSelector)
+
+	names := []string{}
+	for _, node := range nodes {
+		if selector.Matches(labels.Set(node.Labels)) {
+			names = append(names, node.Name)
+		}
+	}
+
+	slices.Sort(names)
+
+	if canary.Percentage > 0 {
+		// rounded up so that there is always a canary node
+		count := (len(names)*int(canary.Percentage) + 99) / 100
+		names = names[:min(count, len(names))]
+	}
+
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	return selected
+}
+
+func podReady(pod *v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// planRollout counts the nodes of every revision and, for canary rollouts,
+// picks the outdated pods to replace next. The canary nodes are updated first
+// and the rest only once all of them are ready. At most maxUnavailable nodes
+// are unavailable at a time.
+func planRollout(rollout *networkv1alpha1.RolloutSpec, hashes map[string]int64, current int64,
+	pods []v1.Pod, canary map[string]bool, now time.Time) rolloutPlan {
+	plan := rolloutPlan{
+		status: networkv1alpha1.RolloutStatus{
+			Revision:    current,
+			CanaryNodes: int32(len(canary)),
+		},
+	}
+
+	byRevision := map[int64]*networkv1alpha1.RevisionStatus{}
+
+	var outdated, outdatedCanary []*v1.Pod
+
+	unavailable := 0
+	canaryReady := true
+
+	slices.SortFunc(pods, func(a, b v1.Pod) int { return strings.Compare(a.Spec.NodeName, b.Spec.NodeName) })
+
+	for i := range pods {
+		pod := &pods[i]
+
+		if pod.Spec.NodeName == "" || !pod.DeletionTimestamp.IsZero() {
+			unavailable++
+
+			continue
+		}
+
+		revision := hashes[pod.Labels[apps.DefaultDaemonSetUniqueLabelKey]]
+		ready := podReady(pod)
+
+		status, found := byRevision[revision]
+		if !found {
+			status = &networkv1alpha1.RevisionStatus{Revision: revision}
+			byRevision[revision] = status
+		}
+
+		status.Nodes++
+		if ready {
+			status.ReadyNodes++
+		} else {
+			unavailable++
+		}
+
+		switch {
+		case revision != current && canary[pod.Spec.NodeName]:
+			outdatedCanary = append(outdatedCanary, pod)
+		case revision != current:
+			outdated = append(outdated, pod)
+		case canary[pod.Spec.NodeName] && !ready:
+			canaryReady = false
+
+			if reason := podFailure(pod, now); reason != "" {
+				plan.failures = append(plan.failures, networkv1beta1.PolicyError{
+					Node:    pod.Spec.NodeName,
+					Message: fmt.Sprintf("rollout halted, canary node %s failed: %s", pod.Spec.NodeName, reason),
+				})
+			}
+		}
+	}
+
+	for _, status := range byRevision {
+		plan.status.Revisions = append(plan.status.Revisions, *status)
+	}
+
+	slices.SortFunc(plan.status.Revisions, func(a, b networkv1alpha1.RevisionStatus) int {
+		return cmp.Compare(b.Revision, a.Revision)
+	})
+
+	switch {
+	case len(outdated)+len(outdatedCanary) == 0 && unavailable == 0:
+		plan.status.Phase = rolloutComplete
+	case rollout != nil && rollout.Paused:
+		plan.status.Phase = rolloutPaused
+	case len(plan.failures) > 0:
+		plan.status.Phase = rolloutHalted
+	default:
+		plan.status.Phase = rolloutProgressing
+	}
+
+	// the DaemonSet controller replaces the pods of the other rollouts
+	if plan.status.Phase != rolloutProgressing || rollout == nil || rollout.Canary == nil {
+		return plan
+	}
+
+	candidates := outdatedCanary
+	if len(candidates) == 0 && canaryReady {
+		candidates = outdated
+	}
+
+	maxUnavailable := 1
+	if rollout.MaxUnavailable != nil {
+		maxUnavailable, _ = intstr.GetScaledValueFromIntOrPercent(rollout.MaxUnavailable, len(pods), true)
+		maxUnavailable = max(maxUnavailable, 1)
+	}
+
+	budget := maxUnavailable - unavailable
+
+	for _, pod := range candidates {
+		// replacing an unavailable pod does not make the rollout any riskier
+		if !podReady(pod) {
+			plan.replace = append(plan.replace, pod)
+
+			continue
+		}
+
+		if budget > 0 {
+			plan.replace = append(plan.replace, pod)
+			budget--
+		}
+	}
+
+	return plan
+}
+
+// keepHalted keeps a halted rollout halted until the policy or the revision
+// changes, also when the failed canary nodes recover or their failures age
+// out of the grace period. Only a rollout with nothing left to update ends.
+func keepHalted(plan *rolloutPlan, previous *networkv1alpha1.RolloutStatus, generation int64) {
+	if plan.status.Phase == rolloutHalted {
+		plan.status.HaltedGeneration = generation
+
+		return
+	}
+
+	if plan.status.Phase == rolloutComplete || previous == nil || previous.Phase != rolloutHalted ||
+		previous.Revision != plan.status.Revision || previous.HaltedGeneration != generation {
+		return
+	}
+
+	plan.status.Phase = rolloutHalted
+	plan.status.HaltedGeneration = generation
+	plan.replace = nil
+	plan.failures = []networkv1beta1.PolicyError{{
+		Message: fmt.Sprintf("rollout of revision %d halted after a canary node failed, update the policy to resume it", plan.status.Revision),
+	}}
+}
+
+// progressRollout observes the rollout of the DaemonSet and takes the next
+// step in the canary rollouts. It returns nil when the DaemonSet controller
+// has not caught up with the latest DaemonSet changes yet.
+func (r *NetworkClusterPolicyReconciler) progressRollout(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy, ds *apps.DaemonSet) (*rolloutPlan, error) {
+	if ds.Status.ObservedGeneration < ds.Generation {
+		return nil, nil
+	}
+
+	hashes, current, err := r.daemonSetRevisions(ctx, ds)
+	if err != nil || current == 0 {
+		return nil, err
+	}
+
+	var pods v1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(ds.Namespace), client.MatchingFields{ownerKey: ds.Name}); err != nil {
+		return nil, err
+	}
+
+	var canary map[string]bool
+
+	if rollout := cr.Spec.Rollout; rollout != nil && rollout.Canary != nil {
+		var nodes v1.NodeList
+		if err := r.List(ctx, &nodes); err != nil {
+			return nil, err
+		}
+
+		// only the nodes running the node agent are targeted
+		targeted := slices.DeleteFunc(nodes.Items, func(node v1.Node) bool {
+			return !slices.ContainsFunc(pods.Items, func(pod v1.Pod) bool { return pod.Spec.NodeName == node.Name })
+		})
+
+		canary = canaryNodes(rollout.Canary, targeted)
+	}
+
+	plan := planRollout(cr.Spec.Rollout, hashes, current, pods.Items, canary, time.Now())
+	keepHalted(&plan, cr.Status.Rollout, cr.Generation)
+
+	if len(plan.replace) == 0 {
+		return &plan, nil
+	}
+
+	nodes := make([]string, 0, len(plan.replace))
+
+	for _, pod := range plan.replace {
+		log.Info("Replacing outdated discovery pod", "pod", pod.Name, "node", pod.Spec.NodeName, "revision", current)
+
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, pod.Spec.NodeName)
+	}
+
+	r.recorder.Eventf(cr, v1.EventTypeNormal, reasonRolloutProgress,
+		"Updating nodes %s to revision %d", strings.Join(nodes, ", "), current)
+
+	return &plan, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2025 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	networkv1alpha1 "github.com/intel/network-operator/api/v1alpha1"
+)
+
+const (
+	defaultReadinessTaintKey = "intel.com/scale-out-not-ready"
+
+	// the readiness taint keys the policy may have put on the nodes
+	readinessTaintAnnotation = "intel.com/readiness-taint-keys"
+)
+
+func readinessTaintKey(cr *networkv1alpha1.NetworkClusterPolicy) string {
+	if t := cr.Spec.ReadinessTaint; t != nil && t.Key != "" {
+		return t.Key
+	}
+
+	return defaultReadinessTaintKey
+}
+
+// addReadinessToleration lets the node agent run on the nodes tainted until
+// it has configured them.
+func addReadinessToleration(ds *apps.DaemonSet, key string) {
+	toleration := v1.Toleration{
+		Key:      key,
+		Operator: v1.TolerationOpExists,
+		Effect:   v1.TaintEffectNoSchedule,
+	}
+
+	if !slices.ContainsFunc(ds.Spec.Template.Spec.Tolerations, func(t v1.Toleration) bool { return t == toleration }) {
+		ds.Spec.Template.Spec.Tolerations = append(ds.Spec.Template.Spec.Tolerations, toleration)
+	}
+}
+
+// recordedTaintKeys returns the readiness taint keys recorded on the policy.
+func recordedTaintKeys(cr *networkv1alpha1.NetworkClusterPolicy) []string {
+	if value := cr.Annotations[readinessTaintAnnotation]; value != "" {
+		return strings.Split(value, ",")
+	}
+
+	return []string{}
+}
+
+// recordTaintKeys records the readiness taint keys on the policy, so that
+// the taints of a changed or removed key are found in the later reconciles.
+func (r *NetworkClusterPolicyReconciler) recordTaintKeys(ctx context.Context, cr *networkv1alpha1.NetworkClusterPolicy, keys []string) error {
+	keys = slices.Compact(slices.Sorted(slices.Values(keys)))
+
+	if strings.Join(keys, ",") == cr.Annotations[readinessTaintAnnotation] {
+		return nil
+	}
+
+	if len(keys) > 0 {
+		if cr.Annotations == nil {
+			cr.Annotations = map[string]string{}
+		}
+		cr.Annotations[readinessTaintAnnotation] = strings.Join(keys, ",")
+	} else {
+		delete(cr.Annotations, readinessTaintAnnotation)
+	}
+
+	return r.Update(ctx, cr)
+}
+
+// readinessTaints returns the taints of the node with the readiness taint of
+// the policy added or removed, and whether they changed. The taints of the
+// policy have one of its keys and its name as the value, so that taints of
+// others with the same value are left alone.
+func readinessTaints(node *v1.Node, policy string, keys []string, key string, taint bool, now time.Time) ([]v1.Taint, bool) {
+	taints := []v1.Taint{}
+	found := false
+
+	for _, t := range node.Spec.Taints {
+		if t.Value != policy || t.Effect != v1.TaintEffectNoSchedule || !slices.Contains(keys, t.Key) {
+			taints = append(taints, t)
+
+			continue
+		}
+
+		if taint && t.Key == key && !found {
+			taints = append(taints, t)
+			found = true
+		}
+	}
+
+	if taint && !found {
+		taints = append(taints, v1.Taint{
+			Key:       key,
+			Value:     policy,
+			Effect:    v1.TaintEffectNoSchedule,
+			TimeAdded: &metav1.Time{Time: now},
+		})
+	}
+
+	return taints, len(taints) != len(node.Spec.Taints) || (taint && !found)
+}
+
+// syncReadinessTaints taints the targeted nodes where the node agent is not
+// ready and removes the taint from the rest. Without the readiness taint in
+// the spec, or when the policy is being deleted, all the taints of the policy
+// are removed. The key is recorded on the policy before the nodes are tainted
+// and the earlier keys are forgotten once their taints have been removed.
+func (r *NetworkClusterPolicyReconciler) syncReadinessTaints(ctx context.Context, log logr.Logger, cr *networkv1alpha1.NetworkClusterPolicy, ds *apps.DaemonSet) error {
+	var nodes v1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return err
+	}
+
+	agents, err := r.agentPods(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	enabled := cr.Spec.ReadinessTaint != nil && cr.DeletionTimestamp.IsZero()
+
+	selector, err := cr.Spec.TargetNodeSelector()
+	if err != nil {
+		selector = labels.Nothing()
+	}
+
+	key := readinessTaintKey(cr)
+	keys := append(recordedTaintKeys(cr), key)
+	now := time.Now()
+
+	if enabled {
+		if err := r.recordTaintKeys(ctx, cr, keys); err != nil {
+			return err
+		}
+	}
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+
+		agent := agents[node.Name]
+		taint := enabled && selector.Matches(labels.Set(node.Labels)) && (agent == nil || !podReady(agent))
+
+		taints, changed := readinessTaints(node, cr.Name, keys, key, taint, now)
+		if !changed {
+			continue
+		}
+
+		// the whole list of taints is replaced, so it must not have changed meanwhile
+		patch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+		node.Spec.Taints = taints
+
+		if err := r.Patch(ctx, node, patch); err != nil {
+			return err
+		}
+
+		log.Info("Updated the readiness taint", "node", node.Name, "tainted", taint)
+	}
+
+	if enabled {
+		return r.recordTaintKeys(ctx, cr, []string{key})
+	}
+
+	return r.recordTaintKeys(ctx, cr, nil)
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networkmanager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// UnmanagedDevicesConfFile is the drop-in written under the
+	// NetworkManager conf.d directory
+	UnmanagedDevicesConfFile = "90-intel-network-operator.conf"
+)
+
+// unmanagedDevicesConfig appends the devices to the unmanaged devices of
+// NetworkManager, keeping the ones set by the admin in the other config files.
+func unmanagedDevicesConfig(hwaddrs []net.HardwareAddr) string {
+	devices := make([]string, 0, len(hwaddrs))
+	for _, hwaddr := range hwaddrs {
+		devices = append(devices, "mac:"+hwaddr.String())
+	}
+
+	sort.Strings(devices)
+
+	return fmt.Sprintf("# Created by network-operator, removed on cleanup\n"+
+		"[keyfile]\n"+
+		"unmanaged-devices+=%s\n", strings.Join(devices, ";"))
+}
+
+// WriteUnmanagedDevicesConfig writes a conf.d drop-in that keeps
+// NetworkManager away from the given devices over restarts and reboots.
+func WriteUnmanagedDevicesConfig(confDir string, hwaddrs []net.HardwareAddr) (string, error) {
+	filename := filepath.Join(confDir, UnmanagedDevicesConfFile)
+
+	if err := os.MkdirAll(confDir, 0755); err != nil {
+		return filename, err
+	}
+
+	if err := os.WriteFile(filename, []byte(unmanagedDevicesConfig(hwaddrs)), 0644); err != nil {
+		return filename, fmt.Errorf("could not write NetworkManager config '%s': %v", filename, err)
+	}
+
+	klog.Infof("Wrote NetworkManager unmanaged devices config '%s'", filename)
+
+	return filename, nil
+}
+
+// RemoveUnmanagedDevicesConfig removes the drop-in, if any.
+func RemoveUnmanagedDevicesConfig(confDir string) error {
+	filename := filepath.Join(confDir, UnmanagedDevicesConfFile)
+
+	if err := os.Remove(filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove NetworkManager config '%s': %v", filename, err)
+	}
+
+	return nil
+}
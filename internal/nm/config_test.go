@@ -0,0 +1,64 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networkmanager
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnmanagedDevicesConfig(t *testing.T) {
+	testDir, err := os.MkdirTemp("", "networkoperator.")
+	if err != nil {
+		t.Fatalf("cannot create tmp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	hwaddrA, _ := net.ParseMAC("00:11:22:33:44:56")
+	hwaddrB, _ := net.ParseMAC("00:11:22:33:44:55")
+	confDir := filepath.Join(testDir, "conf.d")
+
+	filename, err := WriteUnmanagedDevicesConfig(confDir, []net.HardwareAddr{hwaddrA, hwaddrB})
+	if err != nil {
+		t.Fatalf("WriteUnmanagedDevicesConfig failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("could not read config: %v", err)
+	}
+	// appended to the list of the admin instead of replacing it
+	if !strings.Contains(string(content), "[keyfile]\nunmanaged-devices+=mac:00:11:22:33:44:55;mac:00:11:22:33:44:56\n") {
+		t.Errorf("unexpected config content:\n%s", content)
+	}
+	if strings.Contains(string(content), "\nunmanaged-devices=") {
+		t.Errorf("config must not replace the unmanaged devices of the admin:\n%s", content)
+	}
+
+	if err := RemoveUnmanagedDevicesConfig(confDir); err != nil {
+		t.Errorf("RemoveUnmanagedDevicesConfig failed: %v", err)
+	}
+	if _, err := os.Stat(filename); err == nil {
+		t.Errorf("config file was not removed")
+	}
+	if err := RemoveUnmanagedDevicesConfig(confDir); err != nil {
+		t.Errorf("removing a missing config should succeed: %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networkmanager
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Wifx/gonetworkmanager/v3"
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// connectionIDPrefix marks the profiles created by the operator
+	connectionIDPrefix = "intel-network-"
+)
+
+// profileNamespace makes the profile UUIDs stable across runs
+var profileNamespace = uuid.MustParse("6f1b3c2e-6a43-4d0c-9f5e-2f0c8c7a4b11")
+
+// ConnectionSettings are the settings of a NetworkManager connection profile
+// as sent over D-Bus.
+type ConnectionSettings map[string]map[string]interface{}
+
+type ConnectionWrapperIf interface {
+	GetSettings() (ConnectionSettings, error)
+	Update(settings ConnectionSettings) error
+	Delete() error
+}
+
+type ConnectionWrapper struct {
+	connection gonetworkmanager.Connection
+}
+
+// Route is a static route of a connection profile.
+type Route struct {
+	Destination *net.IPNet
+	NextHop     net.IP
+}
+
+// ConnectionProfile describes the persistent configuration of a scale-out
+// interface.
+type ConnectionProfile struct {
+	Interface    string
+	HardwareAddr net.HardwareAddr
+	MTU          int
+	Address      *net.IPNet
+	Routes       []Route
+}
+
+func (r *NetworkManager) ListConnections() ([]ConnectionWrapperIf, error) {
+	settings, err := gonetworkmanager.NewSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	connections, err := settings.ListConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped := make([]ConnectionWrapperIf, 0, len(connections))
+	for _, connection := range connections {
+		wrapped = append(wrapped, &ConnectionWrapper{connection: connection})
+	}
+
+	return wrapped, nil
+}
+
+func (r *NetworkManager) AddConnection(settings ConnectionSettings) (ConnectionWrapperIf, error) {
+	nmSettings, err := gonetworkmanager.NewSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	connection, err := nmSettings.AddConnection(gonetworkmanager.ConnectionSettings(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConnectionWrapper{connection: connection}, nil
+}
+
+func (r *NetworkManager) ActivateConnection(connection ConnectionWrapperIf, device DeviceWrapperIf) error {
+	c, ok := connection.(*ConnectionWrapper)
+	if !ok {
+		return fmt.Errorf("unsupported connection type %T", connection)
+	}
+
+	d, ok := device.(*DeviceWrapper)
+	if !ok {
+		return fmt.Errorf("unsupported device type %T", device)
+	}
+
+	_, err := r.nm.ActivateConnection(c.connection, d.device, nil)
+
+	return err
+}
+
+func (c *ConnectionWrapper) GetSettings() (ConnectionSettings, error) {
+	settings, err := c.connection.GetSettings()
+
+	return ConnectionSettings(settings), err
+}
+
+func (c *ConnectionWrapper) Update(settings ConnectionSettings) error {
+	return c.connection.Update(gonetworkmanager.ConnectionSettings(settings))
+}
+
+func (c *ConnectionWrapper) Delete() error {
+	return c.connection.Delete()
+}
+
+func connectionID(netif string) string {
+	return connectionIDPrefix + netif
+}
+
+func prefixLength(ipnet *net.IPNet) uint32 {
+	ones, _ := ipnet.Mask.Size()
+
+	return uint32(ones)
+}
+
+// profileSettings converts the profile to NetworkManager connection settings.
+// The profile never becomes the default route and IPv6 is left alone.
+func profileSettings(profile ConnectionProfile) ConnectionSettings {
+	id := connectionID(profile.Interface)
+
+	ethernet := map[string]interface{}{}
+	if profile.MTU > 0 {
+		ethernet["mtu"] = uint32(profile.MTU)
+	}
+	if len(profile.HardwareAddr) > 0 {
+		ethernet["mac-address"] = []byte(profile.HardwareAddr)
+	}
+
+	ipv4 := map[string]interface{}{
+		"method":        "disabled",
+		"never-default": true,
+	}
+
+	if profile.Address != nil {
+		ipv4["method"] = "manual"
+		ipv4["address-data"] = []map[string]interface{}{{
+			"address": profile.Address.IP.String(),
+			"prefix":  prefixLength(profile.Address),
+		}}
+	}
+
+	if len(profile.Routes) > 0 {
+		routes := make([]map[string]interface{}, 0, len(profile.Routes))
+
+		for _, route := range profile.Routes {
+			data := map[string]interface{}{
+				"dest":   route.Destination.IP.String(),
+				"prefix": prefixLength(route.Destination),
+			}
+			if route.NextHop != nil {
+				data["next-hop"] = route.NextHop.String()
+			}
+
+			routes = append(routes, data)
+		}
+
+		ipv4["route-data"] = routes
+	}
+
+	return ConnectionSettings{
+		"connection": {
+			"id":             id,
+			"uuid":           uuid.NewSHA1(profileNamespace, []byte(id)).String(),
+			"type":           "802-3-ethernet",
+			"interface-name": profile.Interface,
+			"autoconnect":    true,
+		},
+		"802-3-ethernet": ethernet,
+		"ipv4":           ipv4,
+		"ipv6": {
+			"method": "ignore",
+		},
+	}
+}
+
+// operatorConnections returns the profiles created by the operator by
+// interface name.
+func operatorConnections(nm NetworkManagerIf) (map[string]ConnectionWrapperIf, error) {
+	connections, err := nm.ListConnections()
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]ConnectionWrapperIf{}
+
+	for _, connection := range connections {
+		settings, err := connection.GetSettings()
+		if err != nil {
+			return nil, err
+		}
+
+		id, _ := settings["connection"]["id"].(string)
+		netif, _ := settings["connection"]["interface-name"].(string)
+
+		if netif != "" && id == connectionID(netif) {
+			found[netif] = connection
+		}
+	}
+
+	return found, nil
+}
+
+// ApplyConnectionProfiles creates or updates a persistent connection profile
+// for every given interface and activates it. Returns the interfaces that
+// have a profile.
+func ApplyConnectionProfiles(nm NetworkManagerIf, profiles []ConnectionProfile) ([]string, error) {
+	interfaces := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		interfaces = append(interfaces, profile.Interface)
+	}
+
+	devices, err := interfaceDevices(nm, interfaces)
+	if err != nil {
+		return nil, err
+	}
+
+	if devices == nil {
+		return nil, fmt.Errorf("NetworkManager is not running")
+	}
+
+	existing, err := operatorConnections(nm)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []string{}
+
+	for _, profile := range profiles {
+		device, found := devices[profile.Interface]
+		if !found {
+			return applied, fmt.Errorf("interface %s is not known to NetworkManager", profile.Interface)
+		}
+
+		settings := profileSettings(profile)
+
+		connection, found := existing[profile.Interface]
+		if found {
+			err = connection.Update(settings)
+		} else {
+			connection, err = nm.AddConnection(settings)
+		}
+
+		if err != nil {
+			return applied, fmt.Errorf("could not save connection profile for %s: %v", profile.Interface, err)
+		}
+
+		applied = append(applied, profile.Interface)
+
+		if err := device.SetPropertyManaged(true); err != nil {
+			return applied, err
+		}
+
+		if err := nm.ActivateConnection(connection, device); err != nil {
+			return applied, fmt.Errorf("could not activate connection profile for %s: %v", profile.Interface, err)
+		}
+
+		klog.Infof("Activated NetworkManager connection profile for interface %s", profile.Interface)
+	}
+
+	return applied, nil
+}
+
+// RemoveConnectionProfiles deletes the connection profiles created by the
+// operator for the given interfaces.
+func RemoveConnectionProfiles(nm NetworkManagerIf, interfaces []string) error {
+	if _, err := nm.GetPropertyVersion(); err != nil {
+		klog.Info("Couldn't read NetworkManager version. It's probably not running.")
+
+		return nil
+	}
+
+	existing, err := operatorConnections(nm)
+	if err != nil {
+		return err
+	}
+
+	for _, netif := range interfaces {
+		connection, found := existing[netif]
+		if !found {
+			continue
+		}
+
+		if err := connection.Delete(); err != nil {
+			return fmt.Errorf("could not delete connection profile for %s: %v", netif, err)
+		}
+
+		klog.Infof("Removed NetworkManager connection profile for interface %s", netif)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2025 Intel Corporation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package networkmanager
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+type MockConnection struct {
+	settings ConnectionSettings
+	updated  bool
+	deleted  bool
+}
+
+func (c *MockConnection) GetSettings() (ConnectionSettings, error) {
+	return c.settings, nil
+}
+func (c *MockConnection) Update(settings ConnectionSettings) error {
+	c.settings = settings
+	c.updated = true
+	return nil
+}
+func (c *MockConnection) Delete() error {
+	c.deleted = true
+	return nil
+}
+
+func testProfile(netif string) ConnectionProfile {
+	_, addr, _ := net.ParseCIDR("10.210.8.121/30")
+	addr.IP = net.IPv4(10, 210, 8, 121)
+	_, dst, _ := net.ParseCIDR("10.210.0.0/16")
+	hwaddr, _ := net.ParseMAC("00:11:22:33:44:55")
+
+	return ConnectionProfile{
+		Interface:    netif,
+		HardwareAddr: hwaddr,
+		MTU:          8000,
+		Address:      addr,
+		Routes:       []Route{{Destination: dst, NextHop: net.IPv4(10, 210, 8, 122)}},
+	}
+}
+
+func TestProfileSettings(t *testing.T) {
+	settings := profileSettings(testProfile("ethXYZ"))
+
+	if settings["connection"]["id"] != "intel-network-ethXYZ" || settings["connection"]["interface-name"] != "ethXYZ" {
+		t.Errorf("wrong connection settings: %v", settings["connection"])
+	}
+	if settings["connection"]["uuid"] != profileSettings(testProfile("ethXYZ"))["connection"]["uuid"] {
+		t.Errorf("connection UUID is not stable")
+	}
+	if settings["connection"]["uuid"] == profileSettings(testProfile("ethZYX"))["connection"]["uuid"] {
+		t.Errorf("connection UUIDs of different interfaces are the same")
+	}
+	if settings["802-3-ethernet"]["mtu"] != uint32(8000) {
+		t.Errorf("wrong MTU: %v", settings["802-3-ethernet"]["mtu"])
+	}
+
+	ipv4 := settings["ipv4"]
+	if ipv4["method"] != "manual" || ipv4["never-default"] != true {
+		t.Errorf("wrong ipv4 settings: %v", ipv4)
+	}
+
+	addrs := ipv4["address-data"].([]map[string]interface{})
+	if len(addrs) != 1 || addrs[0]["address"] != "10.210.8.121" || addrs[0]["prefix"] != uint32(30) {
+		t.Errorf("wrong address data: %v", addrs)
+	}
+
+	routes := ipv4["route-data"].([]map[string]interface{})
+	if len(routes) != 1 || routes[0]["dest"] != "10.210.0.0" || routes[0]["prefix"] != uint32(16) ||
+		routes[0]["next-hop"] != "10.210.8.122" {
+		t.Errorf("wrong route data: %v", routes)
+	}
+
+	settings = profileSettings(ConnectionProfile{Interface: "ethXYZ"})
+	if settings["ipv4"]["method"] != "disabled" {
+		t.Errorf("profile without address should disable ipv4: %v", settings["ipv4"])
+	}
+}
+
+func TestApplyConnectionProfiles(t *testing.T) {
+	existing := &MockConnection{settings: ConnectionSettings{
+		"connection": {"id": "intel-network-ethXYZ", "interface-name": "ethXYZ"},
+	}}
+	foreign := &MockConnection{settings: ConnectionSettings{
+		"connection": {"id": "Wired connection 1", "interface-name": "ethZYX"},
+	}}
+	added := []ConnectionSettings{}
+	activated := []string{}
+
+	nm := &MockNetworkManager{
+		mockVersionQuery: func() (string, error) {
+			return "1.0.0", nil
+		},
+		mockGetAllDevices: func() ([]DeviceWrapperIf, error) {
+			ret := []DeviceWrapperIf{}
+			for _, iface := range []string{"ethXYZ", "ethZYX"} {
+				ret = append(ret, &MockDevice{
+					mockIface: func() (string, error) {
+						return iface, nil
+					},
+					mockSetManaged: func(manage bool) error {
+						return nil
+					},
+				})
+			}
+			return ret, nil
+		},
+		mockListConnections: func() ([]ConnectionWrapperIf, error) {
+			return []ConnectionWrapperIf{existing, foreign}, nil
+		},
+		mockAddConnection: func(settings ConnectionSettings) (ConnectionWrapperIf, error) {
+			added = append(added, settings)
+			return &MockConnection{settings: settings}, nil
+		},
+		mockActivateConnection: func(connection ConnectionWrapperIf, device DeviceWrapperIf) error {
+			netif, _ := device.GetPropertyInterface()
+			activated = append(activated, netif)
+			return nil
+		},
+	}
+
+	applied, err := ApplyConnectionProfiles(nm, []ConnectionProfile{testProfile("ethXYZ"), testProfile("ethZYX")})
+	if err != nil {
+		t.Fatalf("ApplyConnectionProfiles failed: %v", err)
+	}
+	if len(applied) != 2 || len(activated) != 2 {
+		t.Errorf("expected two profiles applied and activated, got %v and %v", applied, activated)
+	}
+	if !existing.updated {
+		t.Errorf("existing profile should have been updated")
+	}
+	if foreign.updated || len(added) != 1 || added[0]["connection"]["interface-name"] != "ethZYX" {
+		t.Errorf("a new profile should have been added for ethZYX, got %v", added)
+	}
+
+	if _, err := ApplyConnectionProfiles(nm, []ConnectionProfile{testProfile("ethOther")}); err == nil {
+		t.Errorf("applying a profile for an unknown interface should have failed")
+	}
+
+	if err := RemoveConnectionProfiles(nm, []string{"ethXYZ", "ethZYX"}); err != nil {
+		t.Errorf("RemoveConnectionProfiles failed: %v", err)
+	}
+	if !existing.deleted || foreign.deleted {
+		t.Errorf("only the operator profile should have been deleted")
+	}
+
+	nm.mockVersionQuery = func() (string, error) {
+		return "", os.ErrInvalid
+	}
+
+	if _, err := ApplyConnectionProfiles(nm, []ConnectionProfile{testProfile("ethXYZ")}); err == nil {
+		t.Errorf("applying profiles without NetworkManager should have failed")
+	}
+	if err := RemoveConnectionProfiles(nm, []string{"ethXYZ"}); err != nil {
+		t.Errorf("removing profiles without NetworkManager should be a no-op: %v", err)
+	}
+}
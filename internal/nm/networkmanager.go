@@ -26,10 +26,14 @@ import (
 type NetworkManagerIf interface {
 	GetPropertyVersion() (string, error)
 	GetAllDevices() ([]DeviceWrapperIf, error)
+	ListConnections() ([]ConnectionWrapperIf, error)
+	AddConnection(settings ConnectionSettings) (ConnectionWrapperIf, error)
+	ActivateConnection(connection ConnectionWrapperIf, device DeviceWrapperIf) error
 }
 
 type DeviceWrapperIf interface {
 	GetPropertyInterface() (string, error)
+	GetPropertyManaged() (bool, error)
 	SetPropertyManaged(managed bool) error
 }
 
@@ -72,39 +76,93 @@ func (d *DeviceWrapper) GetPropertyInterface() (string, error) {
 	return d.device.GetPropertyInterface()
 }
 
+func (d *DeviceWrapper) GetPropertyManaged() (bool, error) {
+	return d.device.GetPropertyManaged()
+}
+
 func (d *DeviceWrapper) SetPropertyManaged(managed bool) error {
 	return d.device.SetPropertyManaged(managed)
 }
 
-func DisableNetworkManagerForInterfaces(nm NetworkManagerIf, interfaces []string) error {
+// interfaceDevices returns the NetworkManager devices matching the given
+// interface names. A nil map is returned if NetworkManager is not running.
+func interfaceDevices(nm NetworkManagerIf, interfaces []string) (map[string]DeviceWrapperIf, error) {
 	// Check if NetworkManager is accessible
 	_, err := nm.GetPropertyVersion()
 	if err != nil {
 		klog.Info("Couldn't read NetworkManager version. It's probably not running.")
 
-		return nil
+		return nil, nil
 	}
 
 	devices, err := nm.GetAllDevices()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	found := make(map[string]DeviceWrapperIf)
+
 	for _, device := range devices {
 		netif, err := device.GetPropertyInterface()
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if slices.Contains(interfaces, netif) {
-			err = device.SetPropertyManaged(false)
-			if err != nil {
-				return err
-			}
+			found[netif] = device
+		}
+	}
+
+	return found, nil
+}
+
+func setManagedForInterfaces(nm NetworkManagerIf, interfaces []string, managed bool) error {
+	devices, err := interfaceDevices(nm, interfaces)
+	if err != nil {
+		return err
+	}
+
+	for netif, device := range devices {
+		if err := device.SetPropertyManaged(managed); err != nil {
+			return err
+		}
 
+		if managed {
+			klog.Infof("Enabled NetworkManager for interface %s", netif)
+		} else {
 			klog.Infof("Disabled NetworkManager for interface %s", netif)
 		}
 	}
 
 	return nil
 }
+
+func DisableNetworkManagerForInterfaces(nm NetworkManagerIf, interfaces []string) error {
+	return setManagedForInterfaces(nm, interfaces, false)
+}
+
+func EnableNetworkManagerForInterfaces(nm NetworkManagerIf, interfaces []string) error {
+	return setManagedForInterfaces(nm, interfaces, true)
+}
+
+// GetManagedInterfaces returns the NetworkManager managed state of the given
+// interfaces. Interfaces unknown to NetworkManager are not included.
+func GetManagedInterfaces(nm NetworkManagerIf, interfaces []string) (map[string]bool, error) {
+	devices, err := interfaceDevices(nm, interfaces)
+	if err != nil {
+		return nil, err
+	}
+
+	managed := make(map[string]bool, len(devices))
+
+	for netif, device := range devices {
+		m, err := device.GetPropertyManaged()
+		if err != nil {
+			return nil, err
+		}
+
+		managed[netif] = m
+	}
+
+	return managed, nil
+}
@@ -23,8 +23,11 @@ import (
 )
 
 type MockNetworkManager struct {
-	mockVersionQuery  func() (string, error)
-	mockGetAllDevices func() ([]DeviceWrapperIf, error)
+	mockVersionQuery       func() (string, error)
+	mockGetAllDevices      func() ([]DeviceWrapperIf, error)
+	mockListConnections    func() ([]ConnectionWrapperIf, error)
+	mockAddConnection      func(ConnectionSettings) (ConnectionWrapperIf, error)
+	mockActivateConnection func(ConnectionWrapperIf, DeviceWrapperIf) error
 }
 
 func (m *MockNetworkManager) GetPropertyVersion() (string, error) {
@@ -33,15 +36,28 @@ func (m *MockNetworkManager) GetPropertyVersion() (string, error) {
 func (m *MockNetworkManager) GetAllDevices() ([]DeviceWrapperIf, error) {
 	return m.mockGetAllDevices()
 }
+func (m *MockNetworkManager) ListConnections() ([]ConnectionWrapperIf, error) {
+	return m.mockListConnections()
+}
+func (m *MockNetworkManager) AddConnection(settings ConnectionSettings) (ConnectionWrapperIf, error) {
+	return m.mockAddConnection(settings)
+}
+func (m *MockNetworkManager) ActivateConnection(connection ConnectionWrapperIf, device DeviceWrapperIf) error {
+	return m.mockActivateConnection(connection, device)
+}
 
 type MockDevice struct {
 	mockIface      func() (string, error)
+	mockGetManaged func() (bool, error)
 	mockSetManaged func(bool) error
 }
 
 func (d *MockDevice) GetPropertyInterface() (string, error) {
 	return d.mockIface()
 }
+func (d *MockDevice) GetPropertyManaged() (bool, error) {
+	return d.mockGetManaged()
+}
 func (d *MockDevice) SetPropertyManaged(manage bool) error {
 	return d.mockSetManaged(manage)
 }
@@ -173,3 +189,56 @@ func TestDisableNetworkManagerForInterfacesError(t *testing.T) {
 		}
 	}
 }
+
+func TestGetManagedInterfaces(t *testing.T) {
+	interfaces := []string{"ethXYZ", "ethZYX"}
+	managedState := map[string]bool{"ethXYZ": true, "ethZYX": false, "ethOther": true}
+
+	nm := &MockNetworkManager{
+		mockVersionQuery: func() (string, error) {
+			return "1.0.0", nil
+		},
+		mockGetAllDevices: func() ([]DeviceWrapperIf, error) {
+			ret := []DeviceWrapperIf{}
+			for iface, managed := range managedState {
+				ret = append(ret, &MockDevice{
+					mockIface: func() (string, error) {
+						return iface, nil
+					},
+					mockGetManaged: func() (bool, error) {
+						return managed, nil
+					},
+					mockSetManaged: func(manage bool) error {
+						managedState[iface] = manage
+						return nil
+					},
+				})
+			}
+			return ret, nil
+		},
+	}
+
+	managed, err := GetManagedInterfaces(nm, interfaces)
+	if err != nil {
+		t.Errorf("GetManagedInterfaces failed: %v", err)
+	}
+	if len(managed) != 2 || !managed["ethXYZ"] || managed["ethZYX"] {
+		t.Errorf("unexpected managed state: %v", managed)
+	}
+
+	if err := EnableNetworkManagerForInterfaces(nm, []string{"ethZYX"}); err != nil {
+		t.Errorf("EnableNetworkManagerForInterfaces failed: %v", err)
+	}
+	if !managedState["ethZYX"] {
+		t.Errorf("interface ethZYX was not set managed")
+	}
+
+	nm.mockVersionQuery = func() (string, error) {
+		return "", os.ErrInvalid
+	}
+
+	managed, err = GetManagedInterfaces(nm, interfaces)
+	if err != nil || len(managed) != 0 {
+		t.Errorf("expected no managed state without NetworkManager, got %v: %v", managed, err)
+	}
+}